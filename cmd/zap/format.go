@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"unicode"
+
+	"github.com/dnlvgl/zap/internal/kill"
+)
+
+// defaultTableFormat is used for a bare "--format table".
+const defaultTableFormat = `{{.Port}}\t{{.Protocol}}\t{{.PID}}\t{{.Action}}`
+
+// formatRow is one listener as seen by --format templates: the process
+// record plus the port this row is about.
+type formatRow struct {
+	record
+	Port      int
+	Protocol  string
+	Interface string
+}
+
+// String renders a port record as ":3000/tcp" in templates like {{.Ports}}.
+func (p portRecord) String() string {
+	return fmt.Sprintf(":%d/%s", p.Port, p.Protocol)
+}
+
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// formatter renders records through a user-supplied Go template, similar
+// to `docker ps --format`. A leading "table" directive aligns the columns
+// (separated by tabs) and prints a header derived from the field names.
+type formatter struct {
+	tmpl   *template.Template
+	table  bool
+	header string
+}
+
+func newFormatter(format string, noHeader bool) (*formatter, error) {
+	f := &formatter{}
+	if rest, ok := strings.CutPrefix(format, "table"); ok {
+		f.table = true
+		format = strings.TrimSpace(rest)
+		if format == "" {
+			format = defaultTableFormat
+		}
+	}
+	// Allow escaped tabs and newlines, since shells make literal ones awkward
+	format = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format)
+
+	tmpl, err := template.New("format").Funcs(templateFuncs).Parse(format + "\n")
+	if err != nil {
+		return nil, fmt.Errorf("invalid --format template: %w", err)
+	}
+	f.tmpl = tmpl
+	if f.table && !noHeader {
+		f.header = templateHeader(format)
+	}
+	return f, nil
+}
+
+// write renders one line per listener of every action.
+func (f *formatter) write(w io.Writer, actions []kill.Action) error {
+	out := w
+	var tw *tabwriter.Writer
+	if f.table {
+		tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		out = tw
+		if f.header != "" {
+			fmt.Fprintln(out, f.header)
+		}
+	}
+
+	for _, action := range actions {
+		for _, row := range formatRows(newRecord(action)) {
+			if err := f.tmpl.Execute(out, row); err != nil {
+				return fmt.Errorf("executing --format template: %w", err)
+			}
+		}
+	}
+
+	if tw != nil {
+		return tw.Flush()
+	}
+	return nil
+}
+
+// formatRows expands a process record into one row per listening port.
+func formatRows(r record) []formatRow {
+	if len(r.Ports) == 0 {
+		return []formatRow{{record: r}}
+	}
+	rows := make([]formatRow, len(r.Ports))
+	for i, p := range r.Ports {
+		rows[i] = formatRow{record: r, Port: p.Port, Protocol: p.Protocol, Interface: p.Interface}
+	}
+	return rows
+}
+
+var (
+	templateActionRe = regexp.MustCompile(`\{\{-?\s*(.*?)\s*-?\}\}`)
+	fieldChainRe     = regexp.MustCompile(`(?:\.[A-Za-z_]\w*)+`)
+)
+
+// templateHeader builds a header line by replacing each template action with
+// the upper-cased name of the last field it references, e.g.
+// "{{.Port}}\t{{.Container.Name}}" becomes "PORT\tCONTAINER NAME".
+func templateHeader(format string) string {
+	return templateActionRe.ReplaceAllStringFunc(format, func(action string) string {
+		inner := templateActionRe.FindStringSubmatch(action)[1]
+		chains := fieldChainRe.FindAllString(inner, -1)
+		if len(chains) == 0 {
+			return ""
+		}
+		var words []string
+		for _, name := range strings.Split(strings.TrimPrefix(chains[len(chains)-1], "."), ".") {
+			words = append(words, splitCamel(name)...)
+		}
+		return strings.ToUpper(strings.Join(words, " "))
+	})
+}
+
+// splitCamel splits "UptimeSeconds" into ["Uptime", "Seconds"], keeping
+// acronyms like "PID" together.
+func splitCamel(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		if unicode.IsUpper(runes[i]) && (unicode.IsLower(runes[i-1]) ||
+			(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(words, string(runes[start:]))
+}
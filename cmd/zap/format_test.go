@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dnlvgl/zap/internal/container"
+	"github.com/dnlvgl/zap/internal/kill"
+	"github.com/dnlvgl/zap/internal/process"
+)
+
+func TestTemplateHeader(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"{{.Port}}\t{{.PID}}", "PORT\tPID"},
+		{"{{.Container.Name}}", "CONTAINER NAME"},
+		{"{{ .UptimeSeconds }}", "UPTIME SECONDS"},
+		{"{{.SystemdUnit}} on :{{.Port}}", "SYSTEMD UNIT on :PORT"},
+		{"{{join .Children \",\"}}", "CHILDREN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if got := templateHeader(tt.format); got != tt.want {
+				t.Errorf("templateHeader(%q) = %q, want %q", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatterWrite(t *testing.T) {
+	actions := []kill.Action{
+		{
+			Strategy: kill.StrategyContainer,
+			Context: process.Context{
+				Info: process.Info{
+					PID: 1234,
+					Ports: []process.PortBinding{
+						{Port: 5432, Protocol: "tcp", Interface: "0.0.0.0"},
+						{Port: 5432, Protocol: "tcp6", Interface: "::"},
+					},
+				},
+				Container: &container.Info{ID: "abc123", Name: "db", Runtime: "docker"},
+			},
+		},
+		{
+			Strategy: kill.StrategySignal,
+			Context: process.Context{Info: process.Info{
+				PID:   42,
+				Ports: []process.PortBinding{{Port: 3000, Protocol: "tcp", Interface: "127.0.0.1"}},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		noHeader bool
+		want     string
+	}{
+		{
+			name:   "plain",
+			format: "{{.Port}} {{.PID}} {{.Container.Name}}",
+			want:   "5432 1234 db\n5432 1234 db\n3000 42 \n",
+		},
+		{
+			name:   "table",
+			format: `table {{.Port}}\t{{.Protocol}}\t{{.PID}}`,
+			want: "PORT  PROTOCOL  PID\n" +
+				"5432  tcp       1234\n" +
+				"5432  tcp6      1234\n" +
+				"3000  tcp       42\n",
+		},
+		{
+			name:     "table without header",
+			format:   `table {{.Port}}\t{{.PID}}`,
+			noHeader: true,
+			want:     "5432  1234\n5432  1234\n3000  42\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newFormatter(tt.format, tt.noHeader)
+			if err != nil {
+				t.Fatalf("newFormatter: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := f.write(&buf, actions); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("output:\n%q\nwant:\n%q", buf.String(), tt.want)
+			}
+		})
+	}
+
+	if _, err := newFormatter("{{.Port", false); err == nil {
+		t.Error("expected error for invalid template")
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/dnlvgl/zap/internal/kill"
+)
+
+// runKill resolves the same actions as runDryRun, executes them
+// concurrently and prints one result line per target. When some ports
+// matched nothing, it exits with exitNotFound even if every kill worked.
+func runKill(opts options) {
+	if len(opts.ports) == 0 {
+		fmt.Fprintln(os.Stderr, "error: kill mode needs at least one port")
+		os.Exit(exitError)
+	}
+
+	actions, allFound := resolveActions(opts)
+	if len(actions) == 0 {
+		os.Exit(exitNotFound)
+	}
+
+	errs := make([]error, len(actions))
+	results := make([]kill.Result, len(actions))
+	var wg sync.WaitGroup
+	for i, action := range actions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = kill.ExecuteAndVerify(action)
+		}()
+	}
+	wg.Wait()
+
+	code := exitOK
+	if !allFound {
+		code = exitNotFound
+	}
+	for i, action := range actions {
+		desc := kill.Describe(action)
+		contextInfo := formatContext(action.Context)
+		if errs[i] != nil {
+			fmt.Printf("[failed] %s%s: %v\n", desc, contextInfo, errs[i])
+			code = max(code, failureCode(action, errs[i]))
+			continue
+		}
+		status := "killed"
+		if results[i].PortsKept {
+			status = "done"
+		}
+		fmt.Printf("[%s] %s%s — %s\n", status, desc, contextInfo, results[i])
+	}
+	os.Exit(code)
+}
+
+// failureCode maps a failed action to an exit code. Permission problems
+// get their own code so callers know to retry with sudo.
+func failureCode(action kill.Action, err error) int {
+	if action.Context.OwnerUnknown || errors.Is(err, os.ErrPermission) {
+		return exitPermission
+	}
+	return exitFailed
+}
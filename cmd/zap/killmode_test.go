@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/dnlvgl/zap/internal/kill"
+	"github.com/dnlvgl/zap/internal/process"
+)
+
+func TestFailureCode(t *testing.T) {
+	signal := kill.Action{Strategy: kill.StrategySignal, Context: process.Context{Info: process.Info{PID: 42}}}
+	unit := kill.Action{Strategy: kill.StrategySystemd, Context: process.Context{Info: process.Info{PID: 42}, SystemdUnit: "nginx.service"}}
+	unknownOwner := kill.Action{Strategy: kill.StrategySignal, Context: process.Context{OwnerUnknown: true}}
+
+	tests := []struct {
+		name   string
+		action kill.Action
+		err    error
+		want   int
+	}{
+		{"kill EPERM", signal, fmt.Errorf("%w (process owned by root, try running with sudo)", syscall.EPERM), exitPermission},
+		{"bus access denied", unit, fmt.Errorf("%w (%w, try running with sudo)", errors.New("Access denied"), os.ErrPermission), exitPermission},
+		{"systemctl access denied", unit, fmt.Errorf("systemctl stop nginx.service: Failed to stop nginx.service: Access denied (%w, try running with sudo)", os.ErrPermission), exitPermission},
+		{"docker socket EACCES", unit, fmt.Errorf("/var/run/docker.sock: %w", os.ErrPermission), exitPermission},
+		{"owner unknown", unknownOwner, errors.New("owner of the listening socket is unknown, try running with sudo"), exitPermission},
+		{"job failed", unit, errors.New("stopping nginx.service: job failed"), exitFailed},
+		{"no such container", unit, errors.New("docker API: No such container: gone"), exitFailed},
+	}
+	for _, tt := range tests {
+		if got := failureCode(tt.action, tt.err); got != tt.want {
+			t.Errorf("%s: failureCode = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
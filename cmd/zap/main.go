@@ -4,9 +4,10 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"syscall"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/dnlvgl/zap/internal/container"
 	"github.com/dnlvgl/zap/internal/kill"
 	"github.com/dnlvgl/zap/internal/port"
 	"github.com/dnlvgl/zap/internal/process"
@@ -15,33 +16,103 @@ import (
 
 var version = "dev" // overridden at build time via -ldflags
 
+// Exit codes. exitNotFound, exitFailed and exitPermission are only used by
+// kill mode (--yes / the kill subcommand).
+const (
+	exitOK         = 0
+	exitError      = 1 // invalid arguments or detection failure
+	exitNotFound   = 2 // nothing listens on at least one requested port
+	exitFailed     = 3 // at least one kill failed
+	exitPermission = 4 // at least one target needs elevated privileges
+)
+
 type options struct {
-	force   bool
-	dryRun  bool
-	verbose bool
-	version bool
-	ports   []string
+	force    bool
+	escalate bool
+	grace    time.Duration
+	signal   syscall.Signal
+	strategy *kill.Strategy
+	dryRun   bool
+	yes      bool
+	output   string // outputText, outputJSON or outputNDJSON
+	format   string // Go template for --format
+	noHeader bool
+	verbose  bool
+	version  bool
+	ports    []string
 }
 
 func parseArgs(args []string) options {
 	var opts options
-	for _, arg := range args {
+	// "zap kill :3000" is shorthand for "zap :3000 --yes"
+	if len(args) > 0 && args[0] == "kill" {
+		opts.yes = true
+		args = args[1:]
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		// Flags taking a value accept both "--flag value" and "--flag=value"
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch name {
+		case "--grace", "--signal", "-s", "--strategy", "--format":
+			if !hasValue {
+				if i+1 >= len(args) {
+					fmt.Fprintf(os.Stderr, "missing value for %s\n", name)
+					os.Exit(exitError)
+				}
+				i++
+				value = args[i]
+			}
+			switch name {
+			case "--grace":
+				opts.grace = parseDuration(name, value)
+			case "--signal", "-s":
+				sig, err := kill.ParseSignal(value)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error: %v\n", err)
+					os.Exit(exitError)
+				}
+				opts.signal = sig
+			case "--strategy":
+				strategy, err := kill.ParseStrategy(value)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error: %v\n", err)
+					os.Exit(exitError)
+				}
+				opts.strategy = &strategy
+			case "--format":
+				opts.format = value
+			}
+			continue
+		}
+
 		switch arg {
 		case "--force", "-f":
 			opts.force = true
+		case "--escalate", "-e":
+			opts.escalate = true
 		case "--dry-run", "-n":
 			opts.dryRun = true
+		case "--yes", "-y":
+			opts.yes = true
+		case "--json":
+			opts.output = outputJSON
+		case "--ndjson":
+			opts.output = outputNDJSON
+		case "--no-header":
+			opts.noHeader = true
 		case "--verbose", "-V":
 			opts.verbose = true
 		case "--version", "-v":
 			opts.version = true
 		case "--help", "-h":
 			printUsage()
-			os.Exit(0)
+			os.Exit(exitOK)
 		default:
 			if strings.HasPrefix(arg, "-") {
 				fmt.Fprintf(os.Stderr, "unknown flag: %s\n", arg)
-				os.Exit(1)
+				os.Exit(exitError)
 			}
 			opts.ports = append(opts.ports, arg)
 		}
@@ -49,8 +120,18 @@ func parseArgs(args []string) options {
 	return opts
 }
 
+func parseDuration(flag, value string) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		fmt.Fprintf(os.Stderr, "invalid duration for %s: %q\n", flag, value)
+		os.Exit(exitError)
+	}
+	return d
+}
+
 func printUsage() {
 	fmt.Print(`Usage: zap [flags] [port...]
+       zap kill [flags] port...
 
 Kill processes by port number.
 
@@ -60,10 +141,33 @@ Arguments:
 
 Flags:
   -f, --force     Use SIGKILL instead of SIGTERM
+  -e, --escalate  Send SIGTERM, then SIGKILL if the port is still held
+                  after the grace period
+      --grace D   Grace period before escalating (default 5s)
+  -s, --signal S  Signal to send, e.g. SIGINT, HUP, 10 (default SIGTERM)
+      --strategy  Kill strategy: container, stop-no-restart,
+                  compose-service, compose-project, restart, pause,
+                  resume, remove, systemd, socket, cgroup, tree,
+                  launcher, group or signal (default: picked per
+                  process)
   -n, --dry-run   Show what would be killed without doing it
+  -y, --yes       Kill without asking (same as the kill subcommand)
+      --json      List processes as a JSON array (implies --dry-run)
+      --ndjson    List processes as newline-delimited JSON (implies --dry-run)
+      --format T  Print each listener with a Go template (implies --dry-run),
+                  e.g. '{{.Port}} {{.PID}} {{.Container.Name}}'. Prefix with
+                  "table" for aligned, tab-separated columns with a header.
+      --no-header Omit the header line of --format table
   -V, --verbose   Print extra detection details (strategy, container, unit)
   -v, --version   Print version and exit
   -h, --help      Show this help
+
+Exit codes (kill mode):
+  0  all targets killed
+  1  invalid arguments or detection error
+  2  no processes found on the given ports
+  3  at least one kill failed
+  4  at least one target needs sudo
 `)
 }
 
@@ -72,111 +176,202 @@ func main() {
 
 	if opts.version {
 		fmt.Println("zap " + version)
-		os.Exit(0)
+		os.Exit(exitOK)
 	}
 
-	// Dry-run mode: non-interactive text output
-	if opts.dryRun {
+	if opts.format != "" && opts.output != outputText {
+		fmt.Fprintln(os.Stderr, "error: --format cannot be combined with --json/--ndjson")
+		os.Exit(exitError)
+	}
+	if (opts.output != outputText || opts.format != "") && opts.yes {
+		fmt.Fprintln(os.Stderr, "error: --json/--ndjson/--format cannot be combined with --yes")
+		os.Exit(exitError)
+	}
+
+	// Dry-run mode: non-interactive text, JSON or template output
+	if opts.dryRun || opts.output != outputText || opts.format != "" {
 		runDryRun(opts)
 		return
 	}
 
+	// Kill mode: non-interactive, for scripts
+	if opts.yes {
+		runKill(opts)
+		return
+	}
+
 	// Interactive TUI mode
 	var queries []port.Query
 	for _, arg := range opts.ports {
 		q, err := port.Parse(arg)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
-			os.Exit(1)
+			os.Exit(exitError)
 		}
 		queries = append(queries, q)
 	}
 
-	model := ui.New(queries, opts.force)
+	model := ui.New(queries, ui.Options{
+		Force:       opts.force,
+		Escalate:    opts.escalate,
+		GracePeriod: opts.grace,
+		Strategy:    opts.strategy,
+		Signal:      opts.signal,
+	})
 	p := tea.NewProgram(model, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitError)
+	}
+	// Paused targets keep their ports; don't let them be forgotten
+	if m, ok := final.(ui.Model); ok {
+		for _, resume := range m.Frozen() {
+			ctx := resume.Context
+			fmt.Fprintf(os.Stderr, "warning: PID %d is still frozen; resume it with: zap --strategy resume %d\n",
+				ctx.Info.PID, ctx.Info.Ports[0].Port)
+		}
 	}
 }
 
 func runDryRun(opts options) {
-	queries := opts.ports
-	if len(queries) == 0 {
-		// Dry-run with no ports: show all
-		queries = []string{"1-65535"}
+	var f *formatter
+	if opts.format != "" {
+		var err error
+		if f, err = newFormatter(opts.format, opts.noHeader); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(exitError)
+		}
 	}
 
-	hasError := false
-	for _, arg := range queries {
-		q, err := port.Parse(arg)
-		if err != nil {
+	actions, allFound := resolveActions(opts)
+
+	if f != nil {
+		if err := f.write(os.Stdout, actions); err != nil {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
-			os.Exit(1)
+			os.Exit(exitError)
 		}
-
-		listeners, err := port.Detect(q)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error detecting processes on %s: %v\n", arg, err)
-			os.Exit(1)
+		if !allFound {
+			os.Exit(exitError)
 		}
+		return
+	}
 
-		if len(listeners) == 0 {
-			fmt.Fprintf(os.Stderr, "no processes found listening on %s\n", arg)
-			hasError = true
-			continue
+	if opts.output != outputText {
+		if err := writeRecords(os.Stdout, opts.output, actions); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if !allFound {
+			os.Exit(exitError)
 		}
+		return
+	}
 
-		seen := make(map[int]bool)
-		for _, l := range listeners {
-			if seen[l.PID] {
-				continue
-			}
-			seen[l.PID] = true
+	for _, action := range actions {
+		ctx := action.Context
+		desc := kill.Describe(action)
+		contextInfo := formatContext(ctx)
 
-			ctx, err := process.GatherContext(l.PID, l.Port)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "warning: could not get info for PID %d: %v\n", l.PID, err)
-				continue
+		fmt.Printf("[dry-run] %s%s\n", desc, contextInfo)
+		for _, w := range kill.Warnings(action) {
+			fmt.Printf("  warning: %s\n", w)
+		}
+		if members := kill.CgroupTargets(action); len(members) > 0 {
+			pids := make([]int, len(members))
+			for i, m := range members {
+				pids[i] = m.PID
 			}
-
-			strategy := kill.RecommendedStrategy(ctx)
-			action := kill.Action{
-				Strategy: strategy,
-				Context:  ctx,
-				Force:    opts.force,
+			fmt.Printf("  cgroup PIDs: %v\n", pids)
+		} else if targets := kill.TreeTargets(action); len(targets) > 0 {
+			pids := make([]int, len(targets))
+			for i, n := range targets {
+				pids[i] = n.PID
 			}
-
-			desc := kill.Describe(action)
-			contextInfo := formatContext(ctx, l)
-
-			fmt.Printf("[dry-run] %s%s\n", desc, contextInfo)
-			if len(ctx.Info.Children) > 0 {
-				fmt.Printf("  child PIDs: %v\n", ctx.Info.Children)
+			fmt.Printf("  tree PIDs: %v\n", pids)
+		} else if members := kill.GroupTargets(action); len(members) > 0 {
+			pids := make([]int, len(members))
+			for i, n := range members {
+				pids[i] = n.PID
 			}
-			if opts.verbose {
-				fmt.Printf("  strategy: %s\n", strategy)
-				if ctx.IsContainerized() {
-					fmt.Printf("  container ID: %s (runtime: %s)\n", ctx.Container.ID, ctx.Container.Runtime)
+			fmt.Printf("  process group PIDs: %v\n", pids)
+		} else if len(ctx.Info.Children) > 0 {
+			fmt.Printf("  child PIDs: %v\n", ctx.Info.Children)
+		}
+		if opts.verbose {
+			fmt.Printf("  strategy: %s\n", action.Strategy)
+			if ctx.IsContainerized() {
+				fmt.Printf("  container ID: %s (runtime: %s)\n", ctx.Container.ID, ctx.Container.Runtime)
+				if ctx.Container.Pod != "" {
+					fmt.Printf("  pod: %s\n", ctx.Container.Pod)
+				}
+				if ctx.Container.RestartPolicy != "" {
+					fmt.Printf("  restart policy: %s\n", ctx.Container.RestartPolicy)
+				}
+				if c := ctx.Container.Compose; c != nil {
+					fmt.Printf("  compose: project %s, service %s\n", c.Project, c.Service)
+				}
+				if pod := ctx.Container.Kubernetes; pod != nil {
+					fmt.Printf("  kubernetes pod: %s\n", pod)
+				}
+				if node := ctx.Container.Node; node != nil {
+					fmt.Printf("  kubernetes node: %s\n", node)
 				}
-				if ctx.IsSystemdManaged() {
-					fmt.Printf("  systemd unit: %s\n", ctx.SystemdUnit)
+			}
+			if ctx.IsSystemdManaged() {
+				fmt.Printf("  systemd unit: %s (%s)\n", ctx.SystemdUnit, ctx.SystemdScope)
+				if ctx.SystemdRestart != "" {
+					fmt.Printf("  restart: %s\n", ctx.SystemdRestart)
 				}
-				if ctx.Info.User != "" {
-					fmt.Printf("  user: %s\n", ctx.Info.User)
+			}
+			if s := ctx.SocketUnit; s != nil {
+				fmt.Printf("  socket unit: %s (%s), activates %s\n", s.Unit, s.Scope, strings.Join(s.Activates, ", "))
+			}
+			if s := ctx.ActivatedBy; s != nil {
+				fmt.Printf("  activated by: %s\n", s.Unit)
+			}
+			if g := ctx.Cgroup; g != nil {
+				fmt.Printf("  cgroup: %s\n", g.Path)
+			}
+			if len(ctx.Info.Ancestors) > 0 {
+				parents := make([]string, len(ctx.Info.Ancestors))
+				for i, a := range ctx.Info.Ancestors {
+					parents[i] = fmt.Sprintf("%d %s", a.PID, a.Command)
 				}
+				fmt.Printf("  parents: %s\n", strings.Join(parents, " < "))
+			}
+			if ctx.Info.PGID > 0 {
+				fmt.Printf("  process group: %d, session: %d\n", ctx.Info.PGID, ctx.Info.SID)
+			}
+			if l, ok := ctx.Info.Launcher(); ok {
+				fmt.Printf("  launcher: %d %s (%s)\n", l.PID, l.Command, ctx.Info.TTY)
+			}
+			if ctx.Info.User != "" {
+				fmt.Printf("  user: %s\n", ctx.Info.User)
 			}
 		}
 	}
 
-	if hasError {
-		os.Exit(1)
+	if !allFound {
+		os.Exit(exitError)
 	}
 }
 
-func formatContext(ctx process.Context, l port.Listener) string {
+func formatContext(ctx process.Context) string {
+	addrs := make([]string, len(ctx.Info.Ports))
+	for i, b := range ctx.Info.Ports {
+		addrs[i] = b.Address()
+	}
+	label := "port"
+	if len(addrs) > 1 {
+		label = "ports"
+	}
+	owner := fmt.Sprintf("PID %d", ctx.Info.PID)
+	if ctx.OwnerUnknown {
+		owner = fmt.Sprintf("uid %d/%s", ctx.Info.UID, ctx.Info.User)
+	}
 	parts := []string{
-		fmt.Sprintf(" (PID %d, port %d/%s", ctx.Info.PID, l.Port, l.Protocol),
+		fmt.Sprintf(" (%s, %s %s", owner, label, strings.Join(addrs, ", ")),
 	}
 	if ctx.Info.Command != "" {
 		cmd := ctx.Info.Command
@@ -186,14 +381,23 @@ func formatContext(ctx process.Context, l port.Listener) string {
 		parts = append(parts, fmt.Sprintf(", %s", cmd))
 	}
 	if ctx.IsContainerized() {
-		name := ctx.Container.Name
-		if name == "" {
-			name = container.ShortID(ctx.Container.ID)
-		}
-		parts = append(parts, fmt.Sprintf(", %s container %s", ctx.Container.Runtime, name))
+		parts = append(parts, ", "+ctx.Container.String())
 	}
 	if ctx.IsSystemdManaged() {
-		parts = append(parts, fmt.Sprintf(", systemd %s", ctx.SystemdUnit))
+		if ctx.SystemdScope.User {
+			parts = append(parts, fmt.Sprintf(", systemd user unit %s", ctx.SystemdUnit))
+		} else {
+			parts = append(parts, fmt.Sprintf(", systemd %s", ctx.SystemdUnit))
+		}
+	}
+	if s := ctx.SocketUnit; s != nil {
+		parts = append(parts, fmt.Sprintf(", socket unit %s", s.Unit))
+	}
+	if s := ctx.ActivatedBy; s != nil {
+		parts = append(parts, fmt.Sprintf(", activated by %s", s.Unit))
+	}
+	if ctx.Frozen {
+		parts = append(parts, ", frozen")
 	}
 	return strings.Join(parts, "") + ")"
 }
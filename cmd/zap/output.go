@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/dnlvgl/zap/internal/kill"
+)
+
+// Output formats for listing and dry-run.
+const (
+	outputText   = ""
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+)
+
+// record is the machine-readable view of one listening process and the
+// action zap would take on it.
+type record struct {
+	PID                 int             `json:"pid"`
+	Ports               []portRecord    `json:"ports"`
+	Command             string          `json:"command"`
+	Executable          string          `json:"executable,omitempty"`
+	User                string          `json:"user,omitempty"`
+	UID                 int             `json:"uid"`
+	ParentPID           int             `json:"ppid,omitempty"`
+	PGID                int             `json:"pgid,omitempty"`
+	SID                 int             `json:"sid,omitempty"`
+	MemoryKB            int64           `json:"memory_kb"`
+	StartTime           time.Time       `json:"start_time,omitzero"`
+	UptimeSeconds       int64           `json:"uptime_seconds"`
+	Children            []int           `json:"children"`
+	Ancestors           []nodeRecord    `json:"ancestors,omitempty"`
+	Descendants         []nodeRecord    `json:"descendants,omitempty"`
+	Launcher            *nodeRecord     `json:"launcher,omitempty"`
+	Container           containerRecord `json:"container,omitzero"`
+	SystemdUnit         string          `json:"systemd_unit,omitempty"`
+	SystemdScope        string          `json:"systemd_scope,omitempty"`
+	SystemdRestart      string          `json:"systemd_restart,omitempty"`
+	SocketUnit          string          `json:"socket_unit,omitempty"`
+	Activates           []string        `json:"activates,omitempty"`
+	ActivatedBy         string          `json:"activated_by,omitempty"`
+	Cgroup              *cgroupRecord   `json:"cgroup,omitempty"`
+	Frozen              bool            `json:"frozen,omitempty"`
+	OwnerUnknown        bool            `json:"owner_unknown,omitempty"`
+	RecommendedStrategy string          `json:"recommended_strategy"`
+	Strategy            string          `json:"strategy"`
+	Action              string          `json:"action"`
+	Warnings            []string        `json:"warnings,omitempty"`
+}
+
+type portRecord struct {
+	Port      int    `json:"port"`
+	Protocol  string `json:"protocol"`
+	Interface string `json:"interface"`
+}
+
+type containerRecord struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Runtime string `json:"runtime"`
+	Pod     string `json:"pod,omitempty"`
+	// Namespace is set for Kubernetes containers, whose Pod is then the
+	// Kubernetes pod name.
+	Namespace string         `json:"namespace,omitempty"`
+	Compose   *composeRecord `json:"compose,omitempty"`
+
+	RestartPolicy string `json:"restart_policy,omitempty"`
+}
+
+type nodeRecord struct {
+	PID     int    `json:"pid"`
+	Command string `json:"command"`
+	Depth   int    `json:"depth,omitempty"` // descendants: 1 for a direct child
+}
+
+type cgroupRecord struct {
+	Path string `json:"path"`
+	PIDs []int  `json:"pids"`
+}
+
+type composeRecord struct {
+	Project           string   `json:"project"`
+	Service           string   `json:"service"`
+	ServiceContainers []string `json:"service_containers"`
+	ProjectContainers []string `json:"project_containers"`
+}
+
+// newRecord flattens an action and its process context into a record.
+func newRecord(action kill.Action) record {
+	ctx := action.Context
+	info := ctx.Info
+
+	r := record{
+		PID:                 info.PID,
+		Ports:               make([]portRecord, len(info.Ports)),
+		Command:             info.Command,
+		Executable:          info.Executable,
+		User:                info.User,
+		UID:                 info.UID,
+		ParentPID:           info.ParentPID,
+		PGID:                info.PGID,
+		SID:                 info.SID,
+		MemoryKB:            info.MemoryKB,
+		StartTime:           info.StartTime,
+		UptimeSeconds:       int64(info.Uptime().Seconds()),
+		Children:            append([]int{}, info.Children...),
+		SystemdUnit:         ctx.SystemdUnit,
+		SystemdRestart:      ctx.SystemdRestart,
+		Frozen:              ctx.Frozen,
+		OwnerUnknown:        ctx.OwnerUnknown,
+		RecommendedStrategy: kill.RecommendedStrategy(ctx).String(),
+		Strategy:            action.Strategy.String(),
+		Action:              kill.Describe(action),
+		Warnings:            kill.Warnings(action),
+	}
+	if ctx.IsSystemdManaged() {
+		r.SystemdScope = ctx.SystemdScope.String()
+	}
+	if s := ctx.SocketUnit; s != nil {
+		r.SocketUnit = s.Unit
+		r.SystemdScope = s.Scope.String()
+		r.Activates = s.Activates
+	}
+	if s := ctx.ActivatedBy; s != nil {
+		r.ActivatedBy = s.Unit
+	}
+	if g := ctx.Cgroup; g != nil {
+		r.Cgroup = &cgroupRecord{Path: g.Path, PIDs: g.PIDs()}
+	}
+	for _, a := range info.Ancestors {
+		r.Ancestors = append(r.Ancestors, nodeRecord{PID: a.PID, Command: a.Command})
+	}
+	for _, d := range info.Descendants {
+		r.Descendants = append(r.Descendants, nodeRecord{PID: d.PID, Command: d.Command, Depth: d.Depth})
+	}
+	if l, ok := info.Launcher(); ok {
+		r.Launcher = &nodeRecord{PID: l.PID, Command: l.Command}
+	}
+	for i, b := range info.Ports {
+		r.Ports[i] = portRecord{Port: b.Port, Protocol: b.Protocol, Interface: b.Interface}
+	}
+	if ctx.IsContainerized() {
+		r.Container = containerRecord{
+			ID:      ctx.Container.ID,
+			Name:    ctx.Container.Name,
+			Runtime: ctx.Container.Runtime,
+			Pod:     ctx.Container.Pod,
+
+			RestartPolicy: ctx.Container.RestartPolicy,
+		}
+		if c := ctx.Container.Compose; c != nil {
+			r.Container.Compose = &composeRecord{
+				Project:           c.Project,
+				Service:           c.Service,
+				ServiceContainers: c.ServiceContainers,
+				ProjectContainers: c.ProjectContainers,
+			}
+		}
+		if pod := ctx.Container.Kubernetes; pod != nil {
+			r.Container.Pod = pod.Name
+			r.Container.Namespace = pod.Namespace
+		}
+	}
+	return r
+}
+
+// writeRecords writes one record per action as a JSON array or as
+// newline-delimited JSON.
+func writeRecords(w io.Writer, format string, actions []kill.Action) error {
+	records := make([]record, len(actions))
+	for i, action := range actions {
+		records[i] = newRecord(action)
+	}
+
+	enc := json.NewEncoder(w)
+	if format == outputNDJSON {
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dnlvgl/zap/internal/container"
+	"github.com/dnlvgl/zap/internal/kill"
+	"github.com/dnlvgl/zap/internal/process"
+)
+
+func TestWriteRecords(t *testing.T) {
+	actions := []kill.Action{
+		{
+			Strategy: kill.StrategyContainer,
+			Context: process.Context{
+				Info: process.Info{
+					PID:     1234,
+					Command: "postgres",
+					Ports:   []process.PortBinding{{Port: 5432, Protocol: "tcp", Interface: "0.0.0.0"}},
+				},
+				Container: &container.Info{ID: "abc123", Name: "db", Runtime: "docker"},
+			},
+		},
+		{
+			Strategy: kill.StrategySignal,
+			Context:  process.Context{Info: process.Info{PID: 42, Command: "node"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeRecords(&buf, outputNDJSON, actions); err != nil {
+		t.Fatalf("writeRecords: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d:\n%s", len(lines), buf.String())
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got["action"] != "docker stop db" {
+		t.Errorf("action = %v", got["action"])
+	}
+	if c, ok := got["container"].(map[string]any); !ok || c["name"] != "db" {
+		t.Errorf("container = %v", got["container"])
+	}
+
+	got = nil
+	if err := json.Unmarshal([]byte(lines[1]), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if _, ok := got["container"]; ok {
+		t.Errorf("expected container to be omitted for bare process, got %v", got["container"])
+	}
+	if children, ok := got["children"].([]any); !ok || len(children) != 0 {
+		t.Errorf("children = %v, want empty array", got["children"])
+	}
+
+	buf.Reset()
+	if err := writeRecords(&buf, outputJSON, actions); err != nil {
+		t.Fatalf("writeRecords: %v", err)
+	}
+	var all []record
+	if err := json.Unmarshal(buf.Bytes(), &all); err != nil || len(all) != 2 {
+		t.Errorf("JSON array: %v, %d records", err, len(all))
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dnlvgl/zap/internal/container"
+	"github.com/dnlvgl/zap/internal/kill"
+	"github.com/dnlvgl/zap/internal/port"
+	"github.com/dnlvgl/zap/internal/process"
+)
+
+// resolveActions detects the listeners for the requested ports and builds
+// one kill action per process, honouring the strategy, signal and
+// escalation flags. Listeners of all queries are gathered together, so a
+// process matched by overlapping queries gets a single action. It exits on
+// invalid ports or detection errors. allFound is false when any query
+// matched no listener.
+func resolveActions(opts options) (actions []kill.Action, allFound bool) {
+	queries := opts.ports
+	if len(queries) == 0 {
+		// No ports: show all
+		queries = []string{"1-65535"}
+	}
+
+	allFound = true
+	var listeners []port.Listener
+	for _, arg := range queries {
+		q, err := port.Parse(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(exitError)
+		}
+
+		found, err := port.Detect(q)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error detecting processes on %s: %v\n", arg, err)
+			os.Exit(exitError)
+		}
+
+		if len(found) == 0 {
+			fmt.Fprintf(os.Stderr, "no processes found listening on %s\n", arg)
+			allFound = false
+			continue
+		}
+		listeners = append(listeners, found...)
+	}
+	if len(listeners) == 0 {
+		return nil, allFound
+	}
+
+	// GatherListeners groups by PID and drops listeners seen twice
+	contexts, errs := process.GatherListeners(listeners, container.NewSnapshot())
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "warning: could not get info for %v\n", err)
+	}
+	for _, ctx := range contexts {
+		strategy, ok := kill.ChooseStrategy(ctx, opts.strategy)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "warning: strategy %s not available for PID %d, using %s\n", *opts.strategy, ctx.Info.PID, strategy)
+		}
+		actions = append(actions, kill.Action{
+			Strategy:    strategy,
+			Context:     ctx,
+			Force:       opts.force,
+			Signal:      opts.signal,
+			Escalate:    opts.escalate,
+			GracePeriod: opts.grace,
+		})
+	}
+	return actions, allFound
+}
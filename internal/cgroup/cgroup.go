@@ -0,0 +1,27 @@
+// Package cgroup finds the cgroup v2 a process runs in and signals every
+// process in it at once.
+package cgroup
+
+// Group is a cgroup v2 and every process in it, including its child
+// cgroups.
+type Group struct {
+	Path      string // relative to the cgroup2 mount, e.g. "/system.slice/nginx.service"
+	Members   []Member
+	Frozen    bool // frozen through cgroup.freeze, e.g. by Freeze or docker pause
+	SingleJob bool // every member is in the process group of the PID it was found for
+}
+
+// Member is one process in a Group.
+type Member struct {
+	PID     int
+	Command string // comm, e.g. "node"
+}
+
+// PIDs returns the PID of every member.
+func (g *Group) PIDs() []int {
+	pids := make([]int, len(g.Members))
+	for i, m := range g.Members {
+		pids[i] = m.PID
+	}
+	return pids
+}
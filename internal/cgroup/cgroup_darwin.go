@@ -0,0 +1,28 @@
+//go:build darwin
+
+package cgroup
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// Of returns nil: macOS has no cgroups.
+func Of(pid int) *Group {
+	return nil
+}
+
+// Kill is not supported on macOS.
+func Kill(g *Group, sig syscall.Signal) error {
+	return fmt.Errorf("cgroups are not supported on macOS")
+}
+
+// Freeze is not supported on macOS.
+func Freeze(g *Group) error {
+	return fmt.Errorf("cgroups are not supported on macOS")
+}
+
+// Thaw is not supported on macOS.
+func Thaw(g *Group) error {
+	return fmt.Errorf("cgroups are not supported on macOS")
+}
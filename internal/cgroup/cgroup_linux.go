@@ -0,0 +1,251 @@
+//go:build linux
+
+package cgroup
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// root is the cgroup2 mount point; swapped out in tests.
+var root = "/sys/fs/cgroup"
+
+// freezeTimeout bounds the wait for a cgroup to report itself frozen;
+// swapped out in tests.
+var freezeTimeout = time.Second
+
+// Of returns the cgroup v2 that pid runs in, with all its members. It
+// returns nil when the cgroup can't be killed as a whole without taking
+// unrelated processes along: the root cgroup, a slice, a service
+// manager's init.scope, one that holds zap itself, or a login session
+// scope holding more than pid's job.
+func Of(pid int) *Group {
+	path, ok := pathOf(pid)
+	if !ok || !killable(path) {
+		return nil
+	}
+	if self, ok := pathOf(os.Getpid()); ok && within(self, path) {
+		return nil
+	}
+	dir := filepath.Join(root, path)
+	members := members(dir)
+	if len(members) == 0 {
+		return nil
+	}
+	singleJob := sameJob(pid, members)
+	if isSessionScope(path) && !singleJob {
+		return nil
+	}
+	return &Group{Path: path, Members: members, Frozen: isFrozen(dir), SingleJob: singleJob}
+}
+
+// killable rules out cgroups that are never a single service: the root,
+// slices, and the init.scope of PID 1 or of a user's systemd --user.
+func killable(path string) bool {
+	name := filepath.Base(path)
+	return path != "/" && !strings.HasSuffix(name, ".slice") && name != "init.scope"
+}
+
+// isSessionScope reports whether path is a login session's scope, e.g.
+// "/user.slice/user-1000.slice/session-2.scope".
+func isSessionScope(path string) bool {
+	name := filepath.Base(path)
+	return strings.HasPrefix(name, "session-") && strings.HasSuffix(name, ".scope")
+}
+
+// sameJob reports whether every member is in pid's process group, i.e.
+// the cgroup holds nothing but pid's job.
+func sameJob(pid int, members []Member) bool {
+	pgid, ok := readPGID(pid)
+	if !ok {
+		return false
+	}
+	for _, m := range members {
+		if g, ok := readPGID(m.PID); !ok || g != pgid {
+			return false
+		}
+	}
+	return true
+}
+
+// readPGID reads the process group from /proc/<pid>/stat, the field after
+// state and ppid.
+func readPGID(pid int) (int, bool) {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, false
+	}
+	s := string(data)
+	fields := strings.Fields(s[strings.LastIndex(s, ")")+1:])
+	if len(fields) < 3 {
+		return 0, false
+	}
+	pgid, err := strconv.Atoi(fields[2])
+	return pgid, err == nil
+}
+
+// pathOf reads pid's cgroup v2 path from /proc/<pid>/cgroup.
+func pathOf(pid int) (string, bool) {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return "", false
+	}
+	return parseCgroupPath(string(data))
+}
+
+// parseCgroupPath returns the path of the unified hierarchy, the
+// "0::/path" line.
+func parseCgroupPath(content string) (string, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok && strings.HasPrefix(path, "/") {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// within reports whether cgroup path lies in parent or below it.
+func within(path, parent string) bool {
+	return path == parent || strings.HasPrefix(path, strings.TrimSuffix(parent, "/")+"/")
+}
+
+// members lists the processes of the cgroup at dir and its descendants,
+// sorted by PID.
+func members(dir string) []Member {
+	var list []Member
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(filepath.Join(path, "cgroup.procs"))
+		if err != nil {
+			return nil
+		}
+		for _, field := range strings.Fields(string(data)) {
+			if pid, err := strconv.Atoi(field); err == nil {
+				list = append(list, Member{PID: pid, Command: readComm(pid)})
+			}
+		}
+		return nil
+	})
+	sort.Slice(list, func(i, j int) bool { return list[i].PID < list[j].PID })
+	return list
+}
+
+func readComm(pid int) string {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Kill sends sig to every process in the group. SIGKILL goes through
+// cgroup.kill when the kernel has it (5.14+). Other signals, and SIGKILL
+// on older kernels, are sent to each member while the group is frozen, so
+// nothing can fork a process that escapes.
+func Kill(g *Group, sig syscall.Signal) error {
+	dir := filepath.Join(root, g.Path)
+	if sig == syscall.SIGKILL {
+		err := writeControl(filepath.Join(dir, "cgroup.kill"), "1")
+		if !errors.Is(err, fs.ErrNotExist) {
+			return wrapPermission(err)
+		}
+	}
+
+	if err := freeze(dir, true); err != nil {
+		return wrapPermission(err)
+	}
+	defer freeze(dir, false)
+
+	var errs []error
+	for _, m := range members(dir) {
+		// ESRCH: the process exited meanwhile
+		if err := syscall.Kill(m.PID, sig); err != nil && !errors.Is(err, syscall.ESRCH) {
+			errs = append(errs, fmt.Errorf("PID %d: %w", m.PID, err))
+		}
+	}
+	return wrapPermission(errors.Join(errs...))
+}
+
+// Freeze stops every process in the group until Thaw. Unlike SIGSTOP the
+// processes can't tell, and children forked meanwhile are frozen too.
+func Freeze(g *Group) error {
+	return wrapPermission(freeze(filepath.Join(root, g.Path), true))
+}
+
+// Thaw resumes a group stopped by Freeze.
+func Thaw(g *Group) error {
+	return wrapPermission(freeze(filepath.Join(root, g.Path), false))
+}
+
+// freeze freezes or thaws the cgroup at dir and, when freezing, waits
+// until every process has stopped. A freeze that doesn't complete is
+// undone, so a failed Kill or Freeze never leaves the group frozen.
+func freeze(dir string, frozen bool) error {
+	value := "0"
+	if frozen {
+		value = "1"
+	}
+	if err := writeControl(filepath.Join(dir, "cgroup.freeze"), value); err != nil {
+		return err
+	}
+	if !frozen {
+		return nil
+	}
+	deadline := time.Now().Add(freezeTimeout)
+	for !isFrozen(dir) {
+		if time.Now().After(deadline) {
+			err := fmt.Errorf("cgroup %s did not freeze within %s", dir, freezeTimeout)
+			if thawErr := writeControl(filepath.Join(dir, "cgroup.freeze"), "0"); thawErr != nil {
+				err = fmt.Errorf("%w, and thawing it failed: %w", err, thawErr)
+			}
+			return err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil
+}
+
+// writeControl writes to an existing cgroup interface file; unlike
+// os.WriteFile it never creates one the kernel doesn't provide.
+func writeControl(path, value string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(value)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// isFrozen reads the "frozen" key of cgroup.events.
+func isFrozen(dir string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, "cgroup.events"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "frozen 1" {
+			return true
+		}
+	}
+	return false
+}
+
+func wrapPermission(err error) error {
+	if errors.Is(err, fs.ErrPermission) {
+		return fmt.Errorf("%w (try running with sudo)", err)
+	}
+	return err
+}
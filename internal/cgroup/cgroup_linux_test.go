@@ -0,0 +1,187 @@
+//go:build linux
+
+package cgroup
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseCgroupPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantOK  bool
+	}{
+		{"unified", "0::/system.slice/nginx.service\n", "/system.slice/nginx.service", true},
+		{"hybrid", "12:memory:/docker/abc\n0::/system.slice/docker-abc.scope\n", "/system.slice/docker-abc.scope", true},
+		{"root", "0::/\n", "/", true},
+		{"v1 only", "4:cpu:/user.slice\n1:name=systemd:/user.slice\n", "", false},
+		{"empty", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseCgroupPath(tt.content)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseCgroupPath() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestWithin(t *testing.T) {
+	tests := []struct {
+		path, parent string
+		want         bool
+	}{
+		{"/system.slice/nginx.service", "/system.slice/nginx.service", true},
+		{"/user.slice/session-2.scope/sub", "/user.slice/session-2.scope", true},
+		{"/user.slice/session-20.scope", "/user.slice/session-2.scope", false},
+		{"/system.slice", "/system.slice/nginx.service", false},
+		{"/anything", "/", true},
+	}
+
+	for _, tt := range tests {
+		if got := within(tt.path, tt.parent); got != tt.want {
+			t.Errorf("within(%q, %q) = %v, want %v", tt.path, tt.parent, got, tt.want)
+		}
+	}
+}
+
+func TestKillable(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/system.slice/nginx.service", true},
+		{"/system.slice/docker-abc.scope", true},
+		{"/user.slice/user-1000.slice/user@1000.service/app.slice/vite.service", true},
+		{"/", false},
+		{"/system.slice", false},
+		{"/user.slice/user-1000.slice", false},
+		{"/init.scope", false},
+		{"/user.slice/user-1000.slice/user@1000.service/init.scope", false},
+	}
+
+	for _, tt := range tests {
+		if got := killable(tt.path); got != tt.want {
+			t.Errorf("killable(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSameJob(t *testing.T) {
+	self := os.Getpid()
+	if !sameJob(self, []Member{{PID: self}}) {
+		t.Error("sameJob() = false for the process alone")
+	}
+	other := exec.Command("sleep", "30")
+	other.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := other.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = other.Process.Kill(); _ = other.Wait() }()
+	if sameJob(self, []Member{{PID: self}, {PID: other.Process.Pid}}) {
+		t.Error("sameJob() = true with another job in the scope")
+	}
+	if !isSessionScope("/user.slice/user-1000.slice/session-2.scope") || isSessionScope("/system.slice/sshd.service") {
+		t.Error("isSessionScope() misclassified a path")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMembers(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "cgroup.procs"), "300\n100\n")
+	writeFile(t, filepath.Join(dir, "worker", "cgroup.procs"), "200\n")
+	writeFile(t, filepath.Join(dir, "empty", "cgroup.procs"), "")
+
+	got := (&Group{Members: members(dir)}).PIDs()
+	want := []int{100, 200, 300}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("members() PIDs = %v, want %v", got, want)
+	}
+}
+
+func TestKillUsesCgroupKill(t *testing.T) {
+	old := root
+	root = t.TempDir()
+	defer func() { root = old }()
+
+	dir := filepath.Join(root, "system.slice", "app.service")
+	writeFile(t, filepath.Join(dir, "cgroup.kill"), "")
+	writeFile(t, filepath.Join(dir, "cgroup.procs"), "")
+
+	if err := Kill(&Group{Path: "/system.slice/app.service"}, syscall.SIGKILL); err != nil {
+		t.Fatalf("Kill() error = %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "cgroup.kill"))
+	if string(data) != "1" {
+		t.Errorf("cgroup.kill = %q, want %q", data, "1")
+	}
+}
+
+func TestKillFreezesAndThaws(t *testing.T) {
+	old := root
+	root = t.TempDir()
+	defer func() { root = old }()
+
+	dir := filepath.Join(root, "system.slice", "app.service")
+	writeFile(t, filepath.Join(dir, "cgroup.freeze"), "0")
+	writeFile(t, filepath.Join(dir, "cgroup.events"), "populated 1\nfrozen 1\n")
+	writeFile(t, filepath.Join(dir, "cgroup.procs"), "")
+
+	if err := Kill(&Group{Path: "/system.slice/app.service"}, syscall.SIGTERM); err != nil {
+		t.Fatalf("Kill() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cgroup.kill")); err == nil {
+		t.Error("SIGTERM should not write cgroup.kill")
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "cgroup.freeze"))
+	if string(data) != "0" {
+		t.Errorf("cgroup.freeze = %q, want thawed %q", data, "0")
+	}
+}
+
+func TestFreezeTimeoutThaws(t *testing.T) {
+	oldRoot, oldTimeout := root, freezeTimeout
+	root, freezeTimeout = t.TempDir(), 50*time.Millisecond
+	defer func() { root, freezeTimeout = oldRoot, oldTimeout }()
+
+	dir := filepath.Join(root, "system.slice", "app.service")
+	writeFile(t, filepath.Join(dir, "cgroup.freeze"), "0")
+	// A process stuck in the kernel keeps the group from reporting frozen
+	writeFile(t, filepath.Join(dir, "cgroup.events"), "populated 1\nfrozen 0\n")
+	writeFile(t, filepath.Join(dir, "cgroup.procs"), "")
+
+	g := &Group{Path: "/system.slice/app.service"}
+	for name, run := range map[string]func() error{
+		"Freeze": func() error { return Freeze(g) },
+		"Kill":   func() error { return Kill(g, syscall.SIGTERM) },
+	} {
+		writeFile(t, filepath.Join(dir, "cgroup.freeze"), "0")
+		if err := run(); err == nil {
+			t.Errorf("%s() = nil, want a timeout error", name)
+		}
+		data, _ := os.ReadFile(filepath.Join(dir, "cgroup.freeze"))
+		if string(data) != "0" {
+			t.Errorf("after %s() cgroup.freeze = %q, want thawed %q", name, data, "0")
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// errUnreachable is returned by apiClient when nothing accepts connections
+// on the socket, so callers can fall back to the CLI.
+var errUnreachable = errors.New("API socket unreachable")
+
+// libpodPrefix is the root of Podman's libpod API, which is only served
+// under a version; any version from 4.0.0 on reaches the current handlers.
+const libpodPrefix = "/v4.0.0/libpod"
+
+// statusError is a non-2xx answer from the API, e.g. an endpoint the
+// daemon does not serve, after which callers can retry with the CLI.
+type statusError struct {
+	runtime string
+	message string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s API: %s", e.runtime, e.message)
+}
+
+// apiClient talks to the Docker Engine API, or Podman's compatible and
+// libpod APIs, over a unix socket.
+type apiClient struct {
+	runtime string
+	socket  string
+	http    *http.Client
+}
+
+// newAPIClient returns a client for the first socket of runtime that
+// exists, or nil when there is none.
+func newAPIClient(runtime string) *apiClient {
+	for _, path := range socketCandidates(runtime) {
+		fi, err := os.Stat(path)
+		if err != nil || fi.Mode()&os.ModeSocket == 0 {
+			continue
+		}
+		return &apiClient{
+			runtime: runtime,
+			socket:  path,
+			http: &http.Client{
+				Transport: &http.Transport{
+					DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+						var d net.Dialer
+						return d.DialContext(ctx, "unix", path)
+					},
+				},
+			},
+		}
+	}
+	return nil
+}
+
+// socketCandidates lists the sockets to try for runtime, in order.
+// DOCKER_HOST and CONTAINER_HOST win when set; if they name a non-unix
+// endpoint there is no candidate and the CLI, which understands them, is used.
+func socketCandidates(runtime string) []string {
+	switch runtime {
+	case "docker":
+		if host, ok := os.LookupEnv("DOCKER_HOST"); ok && host != "" {
+			return unixSocketPath(host)
+		}
+		paths := []string{"/var/run/docker.sock"}
+		if home, err := os.UserHomeDir(); err == nil {
+			// Docker Desktop
+			paths = append(paths, filepath.Join(home, ".docker", "run", "docker.sock"))
+		}
+		return paths
+	case "podman":
+		if host, ok := os.LookupEnv("CONTAINER_HOST"); ok && host != "" {
+			return unixSocketPath(host)
+		}
+		var paths []string
+		if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+			paths = append(paths, filepath.Join(dir, "podman", "podman.sock"))
+		}
+		return append(paths, "/run/podman/podman.sock")
+	}
+	return nil
+}
+
+// unixSocketPath extracts the path from a "unix:///path" host.
+func unixSocketPath(host string) []string {
+	if path, ok := strings.CutPrefix(host, "unix://"); ok && path != "" {
+		return []string{path}
+	}
+	return nil
+}
+
+// do sends a request and decodes a JSON response into out, if non-nil.
+// 304 Not Modified (e.g. stopping a stopped container) counts as success.
+func (c *apiClient) do(ctx context.Context, method, path string, out any) error {
+	return c.doJSON(ctx, method, path, nil, out)
+}
+
+// doJSON is do with a JSON-encoded request body, if in is non-nil.
+func (c *apiClient) doJSON(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	// The host is ignored by the unix dialer but must be present
+	req, err := http.NewRequestWithContext(ctx, method, "http://"+c.runtime+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			if errors.Is(err, os.ErrPermission) {
+				// The socket is there but e.g. we are not in the docker group
+				return fmt.Errorf("%s: %w", c.socket, os.ErrPermission)
+			}
+			return fmt.Errorf("%w: %s", errUnreachable, c.socket)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(body, &apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = resp.Status
+		}
+		return &statusError{runtime: c.runtime, message: apiErr.Message}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// listContainers lists the running containers. Podman's libpod endpoint is
+// used for Podman since only it reports pods.
+func (c *apiClient) listContainers(ctx context.Context) ([]entry, error) {
+	if c.runtime == "podman" {
+		var list []podmanContainer
+		if err := c.do(ctx, http.MethodGet, libpodPrefix+"/containers/json", &list); err != nil {
+			return nil, err
+		}
+		return podmanEntries(list), nil
+	}
+	var list []dockerContainer
+	if err := c.do(ctx, http.MethodGet, "/containers/json", &list); err != nil {
+		return nil, err
+	}
+	return dockerEntries(list), nil
+}
+
+func (c *apiClient) containerOp(ctx context.Context, containerID, op string) error {
+	return c.do(ctx, http.MethodPost, "/containers/"+url.PathEscape(containerID)+"/"+op, nil)
+}
+
+func (c *apiClient) podOp(ctx context.Context, pod, op string) error {
+	return c.do(ctx, http.MethodPost, libpodPrefix+"/pods/"+url.PathEscape(pod)+"/"+op, nil)
+}
+
+func (c *apiClient) removeContainer(ctx context.Context, containerID string) error {
+	return c.do(ctx, http.MethodDelete, "/containers/"+url.PathEscape(containerID)+"?force=true", nil)
+}
+
+func (c *apiClient) removePod(ctx context.Context, pod string) error {
+	return c.do(ctx, http.MethodDelete, libpodPrefix+"/pods/"+url.PathEscape(pod)+"?force=true", nil)
+}
+
+// restartPolicy returns the name of a container's restart policy.
+func (c *apiClient) restartPolicy(ctx context.Context, containerID string) (string, error) {
+	var inspect struct {
+		HostConfig hostConfig `json:"HostConfig"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/containers/"+url.PathEscape(containerID)+"/json", &inspect); err != nil {
+		return "", err
+	}
+	return inspect.HostConfig.RestartPolicy.Name, nil
+}
+
+// disableRestart sets a container's restart policy to "no".
+func (c *apiClient) disableRestart(ctx context.Context, containerID string) error {
+	update := map[string]any{"RestartPolicy": map[string]string{"Name": "no"}}
+	return c.doJSON(ctx, http.MethodPost, "/containers/"+url.PathEscape(containerID)+"/update", update, nil)
+}
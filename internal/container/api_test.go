@@ -0,0 +1,421 @@
+package container
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeSocket serves handler on a unix socket and points runtime's host
+// variable at it.
+func fakeSocket(t *testing.T, runtime string, handler http.Handler) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), runtime+".sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(handler)
+	srv.Listener = l
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	if runtime == "podman" {
+		t.Setenv("CONTAINER_HOST", "unix://"+path)
+	} else {
+		t.Setenv("DOCKER_HOST", "unix://"+path)
+	}
+}
+
+const dockerListJSON = `[
+  {"Id": "` + testID + `", "Names": ["/web"],
+   "Ports": [{"IP": "0.0.0.0", "PrivatePort": 80, "PublicPort": 8080, "Type": "tcp"},
+             {"PrivatePort": 443, "Type": "tcp"}],
+   "NetworkSettings": {"Networks": {"bridge": {"IPAddress": "172.17.0.2", "GlobalIPv6Address": ""}}}}
+]`
+
+const podmanListJSON = `[
+  {"Id": "c0ffee", "Names": ["3f2a-infra"], "Pod": "3f2a", "PodName": "stack", "IsInfra": true,
+   "Ports": [{"host_ip": "", "container_port": 5000, "host_port": 5000, "range": 3, "protocol": "tcp"}]},
+  {"Id": "beef", "Names": ["api"], "Pod": "3f2a", "PodName": "stack", "IsInfra": false, "State": "paused", "Ports": null}
+]`
+
+func TestAPIListContainers(t *testing.T) {
+	tests := []struct {
+		runtime string
+		path    string
+		body    string
+		want    []entry
+	}{
+		{
+			runtime: "docker",
+			path:    "/containers/json",
+			body:    dockerListJSON,
+			want: []entry{
+				{ID: testID, Name: "web", IPs: []string{"172.17.0.2"}, Ports: []portRange{{8080, 8080}}},
+			},
+		},
+		{
+			runtime: "podman",
+			path:    "/v4.0.0/libpod/containers/json",
+			body:    podmanListJSON,
+			want: []entry{
+				{ID: "c0ffee", Name: "3f2a-infra", Pod: "stack", Ports: []portRange{{5000, 5002}}},
+				{ID: "beef", Name: "api", Paused: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.runtime, func(t *testing.T) {
+			fakeSocket(t, tt.runtime, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != tt.path {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(tt.body))
+			}))
+
+			got := listContainers(tt.runtime)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listContainers = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnapshotListsOnce(t *testing.T) {
+	var requests, inspects atomic.Int32
+	fakeSocket(t, "docker", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/containers/"+testID+"/json" {
+			inspects.Add(1)
+			w.Write([]byte(`{"HostConfig": {"RestartPolicy": {"Name": "always", "MaximumRetryCount": 0}}}`))
+			return
+		}
+		requests.Add(1)
+		w.Write([]byte(dockerListJSON))
+	}))
+
+	s := NewSnapshot()
+	if e := s.byID("docker", testID); e == nil || e.Name != "web" {
+		t.Errorf("byID = %+v, want web", e)
+	}
+	if e := s.byIP("docker", "172.17.0.2"); e == nil || e.Name != "web" {
+		t.Errorf("byIP = %+v, want web", e)
+	}
+	if info := s.byPort(8080, "docker"); info == nil || info.Name != "web" || info.RestartPolicy != "always" {
+		t.Errorf("byPort = %+v, want web with restart policy always", info)
+	}
+	s.byPort(8080, "docker")
+	if info := s.byPort(443, "docker"); info != nil {
+		t.Errorf("byPort(443) = %+v, want nil for an unpublished port", info)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("list requests = %d, want 1", n)
+	}
+	if n := inspects.Load(); n != 1 {
+		t.Errorf("inspect requests = %d, want 1", n)
+	}
+}
+
+func TestSnapshotRefreshKeepsRestartPolicy(t *testing.T) {
+	var lists, inspects atomic.Int32
+	var stopped atomic.Bool
+	fakeSocket(t, "docker", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/containers/"+testID+"/json" {
+			inspects.Add(1)
+			w.Write([]byte(`{"HostConfig": {"RestartPolicy": {"Name": "always"}}}`))
+			return
+		}
+		lists.Add(1)
+		if stopped.Load() {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(dockerListJSON))
+	}))
+
+	s := NewSnapshot()
+	for range 3 {
+		s = s.Refresh()
+		if info := s.byPort(8080, "docker"); info == nil || info.RestartPolicy != "always" {
+			t.Fatalf("byPort = %+v, want web with restart policy always", info)
+		}
+	}
+	if n := lists.Load(); n != 3 {
+		t.Errorf("list requests = %d, want one per refresh", n)
+	}
+	if n := inspects.Load(); n != 1 {
+		t.Errorf("inspect requests = %d, want 1", n)
+	}
+
+	// Once the container is gone its policy is inspected anew
+	stopped.Store(true)
+	s = s.Refresh()
+	s.containers("docker")
+	stopped.Store(false)
+	s = s.Refresh()
+	s.byPort(8080, "docker")
+	if n := inspects.Load(); n != 2 {
+		t.Errorf("inspect requests after a restart = %d, want 2", n)
+	}
+}
+
+func TestSnapshotInspectsOutsideLock(t *testing.T) {
+	var inspects atomic.Int32
+	release := make(chan struct{})
+	fakeSocket(t, "docker", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/containers/"+testID+"/json" {
+			inspects.Add(1)
+			<-release
+			w.Write([]byte(`{"HostConfig": {"RestartPolicy": {"Name": "always"}}}`))
+			return
+		}
+		w.Write([]byte(dockerListJSON))
+	}))
+	// Runs before the server's cleanup, which waits for the handler
+	t.Cleanup(func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	})
+
+	s := NewSnapshot()
+	e := s.byID("docker", testID)
+	if e == nil {
+		t.Fatal("byID = nil, want web")
+	}
+	results := make(chan string, 2)
+	for range 2 {
+		go func() { results <- s.restartPolicy("docker", e) }()
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for inspects.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		s.mu.Lock()
+		s.mu.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(2 * time.Second):
+		t.Fatal("snapshot stayed locked during the inspect")
+	}
+
+	close(release)
+	for range 2 {
+		if policy := <-results; policy != "always" {
+			t.Errorf("restartPolicy = %q, want always", policy)
+		}
+	}
+	if n := inspects.Load(); n != 1 {
+		t.Errorf("inspect requests = %d, want 1", n)
+	}
+}
+
+func TestAPIOperations(t *testing.T) {
+	var got []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/update") {
+			var update struct{ RestartPolicy struct{ Name string } }
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil || update.RestartPolicy.Name != "no" {
+				t.Errorf("update body = %+v, %v", update, err)
+			}
+		}
+		if r.Method == http.MethodDelete && r.URL.Query().Get("force") != "true" {
+			t.Errorf("%s %s without force=true", r.Method, r.URL)
+		}
+		switch r.URL.Path {
+		case "/containers/gone/stop":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "No such container: gone"}`))
+		case "/containers/stopped/stop":
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	fakeSocket(t, "docker", handler)
+	fakeSocket(t, "podman", handler)
+	// No CLI to fall back to, so API errors are returned as they are
+	t.Setenv("PATH", t.TempDir())
+
+	if err := Stop(testID, "docker"); err != nil {
+		t.Errorf("Stop: %v", err)
+	}
+	if err := Kill(testID, "docker"); err != nil {
+		t.Errorf("Kill: %v", err)
+	}
+	if err := StopPod("stack"); err != nil {
+		t.Errorf("StopPod: %v", err)
+	}
+	if err := Stop("stopped", "docker"); err != nil {
+		t.Errorf("Stop on a stopped container: %v", err)
+	}
+	if err := Restart(testID, "docker"); err != nil {
+		t.Errorf("Restart: %v", err)
+	}
+	if err := Pause(testID, "docker"); err != nil {
+		t.Errorf("Pause: %v", err)
+	}
+	if err := Unpause(testID, "docker"); err != nil {
+		t.Errorf("Unpause: %v", err)
+	}
+	if err := UnpausePod("stack"); err != nil {
+		t.Errorf("UnpausePod: %v", err)
+	}
+	if err := Remove(testID, "docker"); err != nil {
+		t.Errorf("Remove: %v", err)
+	}
+	if err := RemovePod("stack"); err != nil {
+		t.Errorf("RemovePod: %v", err)
+	}
+	if err := DisableRestart(testID, "docker"); err != nil {
+		t.Errorf("DisableRestart: %v", err)
+	}
+	err := Stop("gone", "docker")
+	if err == nil || err.Error() != "docker API: No such container: gone" {
+		t.Errorf("Stop on a missing container = %v", err)
+	}
+
+	want := []string{
+		"POST /containers/" + testID + "/stop",
+		"POST /containers/" + testID + "/kill",
+		"POST /v4.0.0/libpod/pods/stack/stop",
+		"POST /containers/stopped/stop",
+		"POST /containers/" + testID + "/restart",
+		"POST /containers/" + testID + "/pause",
+		"POST /containers/" + testID + "/unpause",
+		"POST /v4.0.0/libpod/pods/stack/unpause",
+		"DELETE /containers/" + testID,
+		"DELETE /v4.0.0/libpod/pods/stack",
+		"POST /containers/" + testID + "/update",
+		"POST /containers/gone/stop",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("requests = %q, want %q", got, want)
+	}
+}
+
+func TestLibpodVersionedPaths(t *testing.T) {
+	// Podman answers unversioned libpod paths with 404
+	fakeSocket(t, "podman", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, "/v4.0.0/libpod/")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if rest == "containers/json" {
+			w.Write([]byte(podmanListJSON))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Setenv("PATH", t.TempDir())
+
+	if got := listContainers("podman"); len(got) != 2 {
+		t.Errorf("listContainers = %+v, want both containers", got)
+	}
+	if err := StopPod("stack"); err != nil {
+		t.Errorf("StopPod: %v", err)
+	}
+	if err := RemovePod("stack"); err != nil {
+		t.Errorf("RemovePod: %v", err)
+	}
+}
+
+func TestAPIErrorFallsBackToCLI(t *testing.T) {
+	fakeSocket(t, "podman", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	dir := t.TempDir()
+	script := "#!/bin/sh\n" +
+		"echo \"$@\" >> " + filepath.Join(dir, "calls") + "\n" +
+		"[ \"$1\" = ps ] && echo '" + strings.ReplaceAll(podmanListJSON, "\n", " ") + "'\n" +
+		"exit 0\n"
+	if err := os.WriteFile(filepath.Join(dir, "podman"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	if got := listContainers("podman"); len(got) != 2 {
+		t.Errorf("listContainers = %+v, want both containers from podman ps", got)
+	}
+	if err := StopPod("stack"); err != nil {
+		t.Errorf("StopPod: %v", err)
+	}
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	if want := "ps --format json\npod stop stack\n"; string(calls) != want {
+		t.Errorf("CLI calls = %q, want %q", calls, want)
+	}
+}
+
+func TestAPIUnreachable(t *testing.T) {
+	// A stale socket file whose server has gone away
+	path := filepath.Join(t.TempDir(), "docker.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	t.Setenv("DOCKER_HOST", "unix://"+path)
+
+	c := newAPIClient("docker")
+	if c == nil {
+		t.Fatal("newAPIClient = nil, want a client for the socket file")
+	}
+	if _, err := c.listContainers(t.Context()); !errors.Is(err, errUnreachable) {
+		t.Errorf("listContainers error = %v, want errUnreachable", err)
+	}
+}
+
+func TestAPIPermissionDenied(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("root can connect to any socket")
+	}
+	fakeSocket(t, "docker", http.NotFoundHandler())
+	path := strings.TrimPrefix(os.Getenv("DOCKER_HOST"), "unix://")
+	if err := os.Chmod(path, 0o400); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := newAPIClient("docker").listContainers(t.Context())
+	if !errors.Is(err, os.ErrPermission) || errors.Is(err, errUnreachable) {
+		t.Errorf("listContainers error = %v, want os.ErrPermission", err)
+	}
+}
+
+func TestSocketCandidates(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "tcp://10.0.0.1:2375")
+	if got := socketCandidates("docker"); got != nil {
+		t.Errorf("docker with tcp DOCKER_HOST = %q, want none", got)
+	}
+	t.Setenv("DOCKER_HOST", "unix:///run/user/1000/docker.sock")
+	if got := socketCandidates("docker"); !reflect.DeepEqual(got, []string{"/run/user/1000/docker.sock"}) {
+		t.Errorf("docker with unix DOCKER_HOST = %q", got)
+	}
+
+	t.Setenv("CONTAINER_HOST", "")
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	want := []string{"/run/user/1000/podman/podman.sock", "/run/podman/podman.sock"}
+	if got := socketCandidates("podman"); !reflect.DeepEqual(got, want) {
+		t.Errorf("podman = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,64 @@
+package container
+
+import (
+	"errors"
+	"sync"
+)
+
+// Labels set by docker compose and podman-compose on every container.
+const (
+	composeProjectLabel = "com.docker.compose.project"
+	composeServiceLabel = "com.docker.compose.service"
+)
+
+// Compose identifies the compose service a container belongs to, with the
+// running containers of that service and of its whole project.
+type Compose struct {
+	Project           string
+	Service           string
+	ServiceContainers []string
+	ProjectContainers []string
+}
+
+// compose returns the compose membership of e within runtime's listing, or
+// nil when e wasn't started by compose.
+func (s *Snapshot) compose(runtime string, e *entry) *Compose {
+	project := e.Labels[composeProjectLabel]
+	if project == "" {
+		return nil
+	}
+	c := &Compose{Project: project, Service: e.Labels[composeServiceLabel]}
+	for _, other := range s.containers(runtime) {
+		if other.Labels[composeProjectLabel] != project {
+			continue
+		}
+		c.ProjectContainers = append(c.ProjectContainers, other.ID)
+		if other.Labels[composeServiceLabel] == c.Service {
+			c.ServiceContainers = append(c.ServiceContainers, other.ID)
+		}
+	}
+	return c
+}
+
+// StopAll stops every container concurrently, or kills them with force,
+// and reports all failures.
+func StopAll(containerIDs []string, runtime string, force bool) error {
+	if len(containerIDs) == 0 {
+		return errors.New("no running containers")
+	}
+	errs := make([]error, len(containerIDs))
+	var wg sync.WaitGroup
+	for i, id := range containerIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if force {
+				errs[i] = Kill(id, runtime)
+			} else {
+				errs[i] = Stop(id, runtime)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
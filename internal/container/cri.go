@@ -0,0 +1,126 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+)
+
+// Runtimes of containers run by the kubelet through CRI. All are driven
+// with crictl, which talks to whichever CRI endpoint is configured.
+const (
+	RuntimeContainerd = "containerd"
+	RuntimeCRIO       = "cri-o"
+	RuntimeCRI        = "cri" // a kubepods cgroup that doesn't name its runtime
+)
+
+// crictl keys the CRI listing in a Snapshot.
+const crictl = "crictl"
+
+// KubernetesPod identifies the pod a CRI container belongs to.
+type KubernetesPod struct {
+	Namespace string
+	Name      string
+}
+
+// String returns "namespace/name".
+func (p KubernetesPod) String() string {
+	return p.Namespace + "/" + p.Name
+}
+
+// KubernetesNode is the docker or podman container a kind or minikube node
+// runs in. Its pods are invisible to crictl on the host, so crictl runs
+// inside it.
+type KubernetesNode struct {
+	ID      string
+	Name    string
+	Runtime string // "docker" or "podman"; empty when neither is available
+}
+
+// String returns the node container's name, or its short ID.
+func (n KubernetesNode) String() string {
+	if n.Name != "" {
+		return n.Name
+	}
+	return ShortID(n.ID)
+}
+
+// crictlCommand returns the command running crictl with args: crictl
+// itself, or `docker exec <node> crictl` for a pod in node.
+func crictlCommand(node *KubernetesNode, args ...string) (string, []string) {
+	if node == nil {
+		return crictl, args
+	}
+	return node.Runtime, append([]string{"exec", node.ID, crictl}, args...)
+}
+
+// IsCRI reports whether runtime is driven through crictl.
+func IsCRI(runtime string) bool {
+	switch runtime {
+	case RuntimeContainerd, RuntimeCRIO, RuntimeCRI:
+		return true
+	}
+	return false
+}
+
+// criContainer is one element of `crictl ps -o json`.
+type criContainer struct {
+	ID       string `json:"id"`
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Labels map[string]string `json:"labels"`
+}
+
+func listCRIContainers(ctx context.Context, node *KubernetesNode) ([]entry, error) {
+	name, args := crictlCommand(node, "ps", "-o", "json")
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, err
+	}
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseCRIList(out)
+}
+
+func parseCRIList(data []byte) ([]entry, error) {
+	var list struct {
+		Containers []criContainer `json:"containers"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	entries := make([]entry, len(list.Containers))
+	for i, c := range list.Containers {
+		e := entry{ID: c.ID, Name: c.Metadata.Name}
+		if name := c.Labels["io.kubernetes.container.name"]; name != "" {
+			e.Name = name
+		}
+		if pod := c.Labels["io.kubernetes.pod.name"]; pod != "" {
+			e.Kubernetes = &KubernetesPod{
+				Namespace: c.Labels["io.kubernetes.pod.namespace"],
+				Name:      pod,
+			}
+		}
+		entries[i] = e
+	}
+	return entries, nil
+}
+
+// StopCRI stops a CRI container, inside node when it runs in one; a zero
+// timeout makes it a kill, since crictl has no separate kill command.
+func StopCRI(containerID string, node *KubernetesNode, force bool) error {
+	args := []string{"stop"}
+	if force {
+		args = append(args, "--timeout", "0")
+	}
+	name, args := crictlCommand(node, append(args, containerID)...)
+	return runOperation(name, nil, args...)
+}
+
+// RemoveCRI force-removes a CRI container, inside node when it runs in one.
+func RemoveCRI(containerID string, node *KubernetesNode) error {
+	name, args := crictlCommand(node, "rm", "--force", containerID)
+	return runOperation(name, nil, args...)
+}
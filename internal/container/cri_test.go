@@ -0,0 +1,66 @@
+package container
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCRIList(t *testing.T) {
+	data := `{"containers": [
+	  {"id": "` + testID + `", "podSandboxId": "5f1e",
+	   "metadata": {"name": "nginx", "attempt": 2},
+	   "labels": {"io.kubernetes.container.name": "nginx",
+	              "io.kubernetes.pod.name": "web-7d9c5b8f4-x2kqp",
+	              "io.kubernetes.pod.namespace": "shop"}},
+	  {"id": "beef", "metadata": {"name": "static"}, "labels": {}}
+	]}`
+
+	got, err := parseCRIList([]byte(data))
+	if err != nil {
+		t.Fatalf("parseCRIList: %v", err)
+	}
+	want := []entry{
+		{ID: testID, Name: "nginx", Kubernetes: &KubernetesPod{Namespace: "shop", Name: "web-7d9c5b8f4-x2kqp"}},
+		{ID: "beef", Name: "static"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCRIList = %+v, want %+v", got, want)
+	}
+}
+
+func TestIsCRI(t *testing.T) {
+	for runtime, want := range map[string]bool{
+		RuntimeContainerd: true,
+		RuntimeCRIO:       true,
+		RuntimeCRI:        true,
+		"docker":          false,
+		"podman":          false,
+	} {
+		if got := IsCRI(runtime); got != want {
+			t.Errorf("IsCRI(%q) = %v, want %v", runtime, got, want)
+		}
+	}
+}
+
+func TestCrictlCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     *KubernetesNode
+		wantName string
+		wantArgs []string
+	}{
+		{"host", nil, "crictl", []string{"stop", "abc"}},
+		{"kind node", &KubernetesNode{ID: "f00d", Name: "kind-control-plane", Runtime: "docker"},
+			"docker", []string{"exec", "f00d", "crictl", "stop", "abc"}},
+		{"podman node", &KubernetesNode{ID: "f00d", Runtime: "podman"},
+			"podman", []string{"exec", "f00d", "crictl", "stop", "abc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, args := crictlCommand(tt.node, "stop", "abc")
+			if name != tt.wantName || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("crictlCommand() = %q %q, want %q %q", name, args, tt.wantName, tt.wantArgs)
+			}
+		})
+	}
+}
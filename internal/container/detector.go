@@ -2,10 +2,10 @@ package container
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
-	"strings"
 	"time"
 )
 
@@ -17,23 +17,169 @@ type Info struct {
 	ID      string
 	Name    string
 	Runtime string // "podman" or "docker"
+	// Pod is set when the ports belong to a Podman pod rather than a single
+	// container; ID and Name then refer to the pod's infra container.
+	Pod string
+	// Kubernetes is set for CRI containers whose pod could be resolved.
+	Kubernetes *KubernetesPod
+	// Node is set for CRI containers of a kind or minikube node.
+	Node *KubernetesNode
+	// Compose is set for containers started by docker/podman compose.
+	Compose *Compose
+	// RestartPolicy is the container's restart policy, e.g. "always" or
+	// "no"; empty when unknown.
+	RestartPolicy string
+	// Paused is set for a container frozen with docker/podman pause.
+	Paused bool
+}
+
+// Restarts reports whether the runtime restarts the container when its
+// process dies. Only "always" also brings it back after a stop, once the
+// runtime itself restarts.
+func (i Info) Restarts() bool {
+	switch i.RestartPolicy {
+	case "", "no":
+		return false
+	}
+	return true
+}
+
+// Reachable reports whether zap can drive the container through its
+// runtime. It is false for a pod in a kind or minikube node when neither
+// docker nor podman is available to run crictl inside the node.
+func (i Info) Reachable() bool {
+	return i.Node == nil || i.Node.Runtime != ""
+}
+
+// DisableRestart sets a container's restart policy to "no", so that a
+// following Stop or Kill sticks.
+func DisableRestart(containerID, runtime string) error {
+	return runOperation(runtime,
+		func(ctx context.Context, c *apiClient) error { return c.disableRestart(ctx, containerID) },
+		"update", "--restart=no", containerID)
 }
 
 // Stop stops a container gracefully.
 func Stop(containerID, runtime string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, runtime, "stop", containerID)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	if IsCRI(runtime) {
+		return StopCRI(containerID, nil, false)
+	}
+	return runOperation(runtime,
+		func(ctx context.Context, c *apiClient) error { return c.containerOp(ctx, containerID, "stop") },
+		"stop", containerID)
 }
 
 // Kill forcefully kills a container.
 func Kill(containerID, runtime string) error {
+	if IsCRI(runtime) {
+		return StopCRI(containerID, nil, true)
+	}
+	return runOperation(runtime,
+		func(ctx context.Context, c *apiClient) error { return c.containerOp(ctx, containerID, "kill") },
+		"kill", containerID)
+}
+
+// Restart restarts a container.
+func Restart(containerID, runtime string) error {
+	if IsCRI(runtime) {
+		return fmt.Errorf("crictl cannot restart containers")
+	}
+	return runOperation(runtime,
+		func(ctx context.Context, c *apiClient) error { return c.containerOp(ctx, containerID, "restart") },
+		"restart", containerID)
+}
+
+// Pause freezes every process of a container.
+func Pause(containerID, runtime string) error {
+	if IsCRI(runtime) {
+		return fmt.Errorf("crictl cannot pause containers")
+	}
+	return runOperation(runtime,
+		func(ctx context.Context, c *apiClient) error { return c.containerOp(ctx, containerID, "pause") },
+		"pause", containerID)
+}
+
+// Unpause resumes a container frozen by Pause.
+func Unpause(containerID, runtime string) error {
+	if IsCRI(runtime) {
+		return fmt.Errorf("crictl cannot unpause containers")
+	}
+	return runOperation(runtime,
+		func(ctx context.Context, c *apiClient) error { return c.containerOp(ctx, containerID, "unpause") },
+		"unpause", containerID)
+}
+
+// Remove force-removes a container, killing it first if it is running.
+// Unlike Stop, this also keeps a --restart=always container from coming back.
+func Remove(containerID, runtime string) error {
+	if IsCRI(runtime) {
+		return RemoveCRI(containerID, nil)
+	}
+	return runOperation(runtime,
+		func(ctx context.Context, c *apiClient) error { return c.removeContainer(ctx, containerID) },
+		"rm", "--force", containerID)
+}
+
+// StopPod stops every container of a Podman pod gracefully.
+func StopPod(pod string) error {
+	return runOperation("podman",
+		func(ctx context.Context, c *apiClient) error { return c.podOp(ctx, pod, "stop") },
+		"pod", "stop", pod)
+}
+
+// KillPod forcefully kills every container of a Podman pod.
+func KillPod(pod string) error {
+	return runOperation("podman",
+		func(ctx context.Context, c *apiClient) error { return c.podOp(ctx, pod, "kill") },
+		"pod", "kill", pod)
+}
+
+// RestartPod restarts every container of a Podman pod.
+func RestartPod(pod string) error {
+	return runOperation("podman",
+		func(ctx context.Context, c *apiClient) error { return c.podOp(ctx, pod, "restart") },
+		"pod", "restart", pod)
+}
+
+// PausePod freezes every container of a Podman pod.
+func PausePod(pod string) error {
+	return runOperation("podman",
+		func(ctx context.Context, c *apiClient) error { return c.podOp(ctx, pod, "pause") },
+		"pod", "pause", pod)
+}
+
+// UnpausePod resumes every container of a Podman pod frozen by PausePod.
+func UnpausePod(pod string) error {
+	return runOperation("podman",
+		func(ctx context.Context, c *apiClient) error { return c.podOp(ctx, pod, "unpause") },
+		"pod", "unpause", pod)
+}
+
+// RemovePod force-removes a Podman pod and its containers.
+func RemovePod(pod string) error {
+	return runOperation("podman",
+		func(ctx context.Context, c *apiClient) error { return c.removePod(ctx, pod) },
+		"pod", "rm", "--force", pod)
+}
+
+// runOperation performs op through the runtime's API socket, or runs the
+// CLI with args when no socket is reachable or the API refuses the request
+// and the CLI is installed. A nil op always uses the CLI.
+func runOperation(runtime string, op func(context.Context, *apiClient) error, args ...string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
 	defer cancel()
-	cmd := exec.CommandContext(ctx, runtime, "kill", containerID)
+	if c := newAPIClient(runtime); c != nil && op != nil {
+		err := op(ctx, c)
+		var status *statusError
+		if errors.As(err, &status) {
+			if _, lookErr := exec.LookPath(runtime); lookErr != nil {
+				return err
+			}
+		} else if !errors.Is(err, errUnreachable) {
+			return err
+		}
+	}
+	cmd := exec.CommandContext(ctx, runtime, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -49,34 +195,15 @@ func ShortID(id string) string {
 
 // String returns a human-readable description of the container.
 func (i Info) String() string {
+	if i.Pod != "" {
+		return fmt.Sprintf("%s pod %s", i.Runtime, i.Pod)
+	}
 	name := i.Name
 	if name == "" {
 		name = ShortID(i.ID)
 	}
-	return fmt.Sprintf("%s container %s", i.Runtime, name)
-}
-
-func getContainerName(containerID, runtime string) string {
-	ctx, cancel := context.WithTimeout(context.Background(), detectionTimeout)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, runtime, "inspect", "--format", "{{.Name}}", containerID)
-	out, err := cmd.Output()
-	if err != nil {
-		// Try short ID (first 12 chars)
-		if len(containerID) > 12 {
-			ctx2, cancel2 := context.WithTimeout(context.Background(), detectionTimeout)
-			defer cancel2()
-			cmd = exec.CommandContext(ctx2, runtime, "inspect", "--format", "{{.Name}}", containerID[:12])
-			out, err = cmd.Output()
-			if err != nil {
-				return ""
-			}
-		} else {
-			return ""
-		}
+	if i.Kubernetes != nil {
+		return fmt.Sprintf("%s container %s in pod %s", i.Runtime, name, i.Kubernetes)
 	}
-	name := strings.TrimSpace(string(out))
-	// Docker prefixes names with /
-	name = strings.TrimPrefix(name, "/")
-	return name
+	return fmt.Sprintf("%s container %s", i.Runtime, name)
 }
@@ -11,17 +11,26 @@ import (
 	"strings"
 )
 
-// libpod-<id>.scope for Podman, docker-<id>.scope for Docker
+// libpod-<id>.scope for Podman, docker-<id>.scope for Docker,
+// cri-containerd-<id>.scope, crio-<id>.scope and, with cri-dockerd as in
+// minikube, docker-<id>.scope below a pod slice for Kubernetes with the
+// systemd cgroup driver, /kubepods/.../pod<uid>/<id> with cgroupfs
 var (
-	libpodRe      = regexp.MustCompile(`libpod-([0-9a-f]{64})`)
-	dockerRe      = regexp.MustCompile(`docker-([0-9a-f]{64})`)
-	slashDockerRe = regexp.MustCompile(`/docker/([0-9a-f]{64})`)
-	slashLXCRe    = regexp.MustCompile(`/lxc/([0-9a-f]{64})`)
+	criContainerdRe = regexp.MustCompile(`cri-containerd-([0-9a-f]{64})`)
+	crioRe          = regexp.MustCompile(`crio-([0-9a-f]{64})`)
+	criDockerdRe    = regexp.MustCompile(`kubepods[^:]*-pod[0-9a-f_]+\.slice/docker-([0-9a-f]{64})\.scope`)
+	kubepodsRe      = regexp.MustCompile(`kubepods[^:]*/pod[0-9a-f_-]+/([0-9a-f]{64})`)
+	libpodRe        = regexp.MustCompile(`libpod-([0-9a-f]{64})`)
+	dockerRe        = regexp.MustCompile(`docker-([0-9a-f]{64})`)
+	slashDockerRe   = regexp.MustCompile(`/docker/([0-9a-f]{64})`)
+	slashLXCRe      = regexp.MustCompile(`/lxc/([0-9a-f]{64})`)
 )
 
 // Detect checks if a process is running inside a container.
+// Docker's userland proxy and rootless Podman's port forwarders are
+// attributed to the container they forward to.
 // Returns nil if the process is not containerized.
-func Detect(pid, port int) *Info {
+func (s *Snapshot) Detect(pid, port int) *Info {
 	cgroupPath := filepath.Join("/proc", strconv.Itoa(pid), "cgroup")
 	data, err := os.ReadFile(cgroupPath)
 	if err != nil {
@@ -30,21 +39,52 @@ func Detect(pid, port int) *Info {
 
 	containerID, runtimeHint := parseCgroup(string(data))
 	if containerID == "" {
-		return nil
+		// Published ports of rootful Docker are held by docker-proxy, those
+		// of rootless Podman by a network helper
+		if info := s.detectDockerProxy(pid); info != nil {
+			return info
+		}
+		return s.detectPodmanHelper(pid, port)
 	}
 
-	runtime := detectRuntime(runtimeHint)
-	name := getContainerName(containerID, runtime)
+	if IsCRI(runtimeHint) {
+		info := &Info{ID: containerID, Runtime: runtimeHint}
+		if nodeID, nodeHint := parseNode(string(data)); nodeID != "" {
+			info.Node = s.node(nodeID, nodeHint)
+		}
+		if e := s.criByID(info.Node, containerID); e != nil {
+			info.Name = e.Name
+			info.Kubernetes = e.Kubernetes
+		}
+		return info
+	}
 
-	return &Info{
-		ID:      containerID,
-		Name:    name,
-		Runtime: runtime,
+	runtime := detectRuntime(runtimeHint)
+	if e := s.byID(runtime, containerID); e != nil {
+		info := s.info(runtime, e)
+		// Keep the full ID from the cgroup
+		info.ID = containerID
+		return info
 	}
+	return &Info{ID: containerID, Runtime: runtime}
 }
 
 func parseCgroup(content string) (containerID, runtime string) {
 	for _, line := range strings.Split(content, "\n") {
+		// Kubernetes first: in kind and similar nodes the pod's cgroup is
+		// nested inside the node's docker-<id>.scope
+		if m := criContainerdRe.FindStringSubmatch(line); len(m) > 1 {
+			return m[1], RuntimeContainerd
+		}
+		if m := crioRe.FindStringSubmatch(line); len(m) > 1 {
+			return m[1], RuntimeCRIO
+		}
+		if m := criDockerdRe.FindStringSubmatch(line); len(m) > 1 {
+			return m[1], RuntimeCRI
+		}
+		if m := kubepodsRe.FindStringSubmatch(line); len(m) > 1 {
+			return m[1], RuntimeCRI
+		}
 		// Podman (libpod)
 		if m := libpodRe.FindStringSubmatch(line); len(m) > 1 {
 			return m[1], "podman"
@@ -65,18 +105,60 @@ func parseCgroup(content string) (containerID, runtime string) {
 	return "", ""
 }
 
+// parseNode returns the docker or podman container that a Kubernetes
+// pod's cgroup is nested in, as in kind and minikube nodes.
+func parseNode(content string) (nodeID, runtime string) {
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.Contains(line, "kubepods") {
+			continue
+		}
+		if m := libpodRe.FindStringSubmatch(line); len(m) > 1 {
+			return m[1], "podman"
+		}
+		if m := dockerRe.FindStringSubmatch(line); len(m) > 1 {
+			return m[1], "docker"
+		}
+		if m := slashDockerRe.FindStringSubmatch(line); len(m) > 1 {
+			return m[1], "docker"
+		}
+	}
+	return "", ""
+}
+
+// node describes the node container nodeID, leaving its runtime empty when
+// neither docker nor podman can be reached to exec into it.
+func (s *Snapshot) node(nodeID, hint string) *KubernetesNode {
+	runtime := detectRuntime(hint)
+	if !available(runtime) {
+		return &KubernetesNode{ID: nodeID}
+	}
+	node := &KubernetesNode{ID: nodeID, Runtime: runtime}
+	if e := s.byID(runtime, nodeID); e != nil {
+		node.Name = e.Name
+	}
+	return node
+}
+
 // detectRuntime verifies which runtime is actually available.
 func detectRuntime(hint string) string {
-	if hint == "podman" {
-		if _, err := exec.LookPath("podman"); err == nil {
-			return "podman"
-		}
+	if hint == "podman" && available("podman") {
+		return "podman"
 	}
-	if _, err := exec.LookPath("docker"); err == nil {
+	if available("docker") {
 		return "docker"
 	}
-	if _, err := exec.LookPath("podman"); err == nil {
+	if available("podman") {
 		return "podman"
 	}
 	return hint
 }
+
+// available reports whether runtime can be reached through its API socket
+// or CLI.
+func available(runtime string) bool {
+	if newAPIClient(runtime) != nil {
+		return true
+	}
+	_, err := exec.LookPath(runtime)
+	return err == nil
+}
@@ -2,7 +2,10 @@
 
 package container
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestParseCgroup(t *testing.T) {
 	tests := []struct {
@@ -35,6 +38,41 @@ func TestParseCgroup(t *testing.T) {
 			wantID:   testID,
 			wantHint: "docker",
 		},
+		{
+			name:     "containerd systemd driver",
+			content:  "0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod0d5c1f2a_3b4c_4d5e_8f90_1a2b3c4d5e6f.slice/cri-containerd-" + testID + ".scope",
+			wantID:   testID,
+			wantHint: RuntimeContainerd,
+		},
+		{
+			name:     "cri-o systemd driver",
+			content:  "0::/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod0d5c1f2a.slice/crio-" + testID + ".scope",
+			wantID:   testID,
+			wantHint: RuntimeCRIO,
+		},
+		{
+			name:     "kubepods cgroupfs driver",
+			content:  "0::/kubepods/burstable/pod0d5c1f2a-3b4c-4d5e-8f90-1a2b3c4d5e6f/" + testID,
+			wantID:   testID,
+			wantHint: RuntimeCRI,
+		},
+		{
+			name:     "kind node nested in docker",
+			content:  "0::/system.slice/docker-" + strings.Repeat("f", 64) + ".scope/kubelet.slice/kubelet-kubepods.slice/kubelet-kubepods-pod1.slice/cri-containerd-" + testID + ".scope",
+			wantID:   testID,
+			wantHint: RuntimeContainerd,
+		},
+		{
+			name:     "minikube node with cri-dockerd",
+			content:  "0::/system.slice/docker-" + strings.Repeat("f", 64) + ".scope/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod0d5c1f2a_3b4c.slice/docker-" + testID + ".scope",
+			wantID:   testID,
+			wantHint: RuntimeCRI,
+		},
+		{
+			name:    "cri-o conmon",
+			content: "0::/kubepods.slice/kubepods-pod1.slice/crio-conmon-" + testID + ".scope",
+			wantID:  "",
+		},
 		{
 			name:    "bare process",
 			content: "0::/user.slice/user-1000.slice/session-1.scope",
@@ -66,3 +104,55 @@ func TestParseCgroup(t *testing.T) {
 		})
 	}
 }
+
+func TestParseNode(t *testing.T) {
+	nodeID := strings.Repeat("f", 64)
+	tests := []struct {
+		name        string
+		content     string
+		wantID      string
+		wantRuntime string
+	}{
+		{
+			name:        "kind node",
+			content:     "0::/system.slice/docker-" + nodeID + ".scope/kubelet.slice/kubelet-kubepods.slice/kubelet-kubepods-pod1.slice/cri-containerd-" + testID + ".scope",
+			wantID:      nodeID,
+			wantRuntime: "docker",
+		},
+		{
+			name:        "kind node on podman",
+			content:     "0::/machine.slice/libpod-" + nodeID + ".scope/kubelet.slice/kubelet-kubepods.slice/kubelet-kubepods-pod1.slice/cri-containerd-" + testID + ".scope",
+			wantID:      nodeID,
+			wantRuntime: "podman",
+		},
+		{
+			name:        "minikube node with cri-dockerd",
+			content:     "0::/system.slice/docker-" + nodeID + ".scope/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod1.slice/docker-" + testID + ".scope",
+			wantID:      nodeID,
+			wantRuntime: "docker",
+		},
+		{
+			name:        "cgroupfs node",
+			content:     "12:memory:/docker/" + nodeID + "/kubepods/burstable/pod0d5c1f2a/" + testID,
+			wantID:      nodeID,
+			wantRuntime: "docker",
+		},
+		{
+			name:    "pod on the host",
+			content: "0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1.slice/cri-containerd-" + testID + ".scope",
+		},
+		{
+			name:    "plain docker container",
+			content: "0::/system.slice/docker-" + testID + ".scope",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, runtime := parseNode(tt.content)
+			if id != tt.wantID || runtime != tt.wantRuntime {
+				t.Errorf("parseNode() = %q, %q; want %q, %q", id, runtime, tt.wantID, tt.wantRuntime)
+			}
+		})
+	}
+}
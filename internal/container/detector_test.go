@@ -20,6 +20,11 @@ func TestInfoString(t *testing.T) {
 		t.Errorf("String = %q", got)
 	}
 
+	k8s := Info{ID: testID, Name: "nginx", Runtime: RuntimeContainerd, Kubernetes: &KubernetesPod{Namespace: "shop", Name: "web-0"}}
+	if got := k8s.String(); got != "containerd container nginx in pod shop/web-0" {
+		t.Errorf("String kubernetes = %q", got)
+	}
+
 	noName := Info{ID: testID, Runtime: "docker"}
 	if got := noName.String(); got != "docker container "+testID[:12] {
 		t.Errorf("String no name = %q", got)
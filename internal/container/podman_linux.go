@@ -0,0 +1,127 @@
+//go:build linux
+
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// libpod-conmon-<id>.scope holds conmon and, with some setups, the
+// rootless network helpers started alongside it.
+var conmonRe = regexp.MustCompile(`libpod-conmon-([0-9a-f]{64})`)
+
+// maxAncestors bounds the walk up the parent chain looking for conmon.
+const maxAncestors = 8
+
+// detectPodmanHelper resolves a rootless Podman port forwarder
+// (rootlessport, pasta or slirp4netns) to the container or pod whose port
+// it forwards. The container ID comes from the helper's cgroup or a conmon
+// ancestor when possible, otherwise the listing is searched by host port.
+func (s *Snapshot) detectPodmanHelper(pid, port int) *Info {
+	if !isPodmanHelper(readComm(pid)) {
+		return nil
+	}
+	if e := matchHelper(s.containers("podman"), helperContainerID(pid), port); e != nil {
+		return s.info("podman", e)
+	}
+	return nil
+}
+
+// isPodmanHelper reports whether comm names one of rootless Podman's port
+// forwarding helpers. comm is truncated to 15 characters, so the re-exec'd
+// "rootlessport-child" shows up as "rootlessport-ch".
+func isPodmanHelper(comm string) bool {
+	return strings.HasPrefix(comm, "rootlessport") ||
+		strings.HasPrefix(comm, "pasta") ||
+		comm == "slirp4netns"
+}
+
+// helperContainerID looks for the container a helper belongs to in its own
+// cgroup and then in those of its ancestors, stopping at the first conmon.
+func helperContainerID(pid int) string {
+	for range maxAncestors {
+		if pid <= 1 {
+			return ""
+		}
+		if data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cgroup")); err == nil {
+			if m := conmonRe.FindStringSubmatch(string(data)); len(m) > 1 {
+				return m[1]
+			}
+			if id, _ := parseCgroup(string(data)); id != "" {
+				return id
+			}
+		}
+		if readComm(pid) == "conmon" {
+			data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cmdline"))
+			if err != nil {
+				return ""
+			}
+			return parseConmonArgs(strings.Split(strings.TrimRight(string(data), "\x00"), "\x00"))
+		}
+		pid = parentPID(pid)
+	}
+	return ""
+}
+
+// parseConmonArgs returns the container ID passed to conmon via -c/--cid.
+func parseConmonArgs(args []string) string {
+	for i := 1; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name != "-c" && name != "--cid" {
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return ""
+			}
+			value = args[i+1]
+		}
+		return value
+	}
+	return ""
+}
+
+func readComm(pid int) string {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// parentPID reads the parent PID from /proc/<pid>/stat, or 0 on error.
+func parentPID(pid int) int {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0
+	}
+	// The command name may contain spaces and parens; fields follow the last ')'
+	s := string(data)
+	fields := strings.Fields(s[strings.LastIndexByte(s, ')')+1:])
+	if len(fields) < 2 {
+		return 0
+	}
+	ppid, _ := strconv.Atoi(fields[1])
+	return ppid
+}
+
+// matchHelper picks the container behind a helper: the one with the given
+// ID (if known), otherwise the one publishing port.
+func matchHelper(entries []entry, id string, port int) *entry {
+	var match *entry
+	for i := range entries {
+		e := &entries[i]
+		if e.matchesID(id) {
+			match = e
+			break
+		}
+		if match == nil && e.publishes(port) {
+			match = e
+		}
+	}
+	return match
+}
@@ -0,0 +1,86 @@
+//go:build linux
+
+package container
+
+import "testing"
+
+func TestIsPodmanHelper(t *testing.T) {
+	for comm, want := range map[string]bool{
+		"rootlessport":    true,
+		"rootlessport-ch": true,
+		"pasta":           true,
+		"pasta.avx2":      true,
+		"slirp4netns":     true,
+		"conmon":          false,
+		"postgres":        false,
+		"":                false,
+	} {
+		if got := isPodmanHelper(comm); got != want {
+			t.Errorf("isPodmanHelper(%q) = %v, want %v", comm, got, want)
+		}
+	}
+}
+
+func TestParseConmonArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"short flag", []string{"/usr/bin/conmon", "--api-version", "1", "-c", testID, "-u", testID}, testID},
+		{"long flag", []string{"conmon", "--cid", testID}, testID},
+		{"equals form", []string{"conmon", "--cid=" + testID}, testID},
+		{"missing value", []string{"conmon", "-c"}, ""},
+		{"no cid", []string{"conmon", "--api-version", "1"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseConmonArgs(tt.args); got != tt.want {
+				t.Errorf("parseConmonArgs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchHelper(t *testing.T) {
+	entries := []entry{
+		{ID: testID, Name: "web", Ports: []portRange{{8080, 8080}}},
+		{ID: "c0ffee", Name: "3f2a-infra", Pod: "stack", Ports: []portRange{{5000, 5002}}},
+		{ID: "beef", Name: "api"},
+	}
+
+	tests := []struct {
+		name     string
+		id       string
+		port     int
+		wantName string
+		wantPod  string
+	}{
+		{"by port", "", 8080, "web", ""},
+		{"by id", testID, 9999, "web", ""},
+		{"by short id", testID[:12], 9999, "web", ""},
+		{"pod by port range", "", 5002, "3f2a-infra", "stack"},
+		{"pod member by id", "beef", 5000, "api", ""},
+		{"unknown id falls back to port", "0000", 8080, "web", ""},
+		{"no match", "", 5003, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := matchHelper(entries, tt.id, tt.port)
+			if tt.wantName == "" {
+				if info != nil {
+					t.Errorf("got %+v, want nil", info)
+				}
+				return
+			}
+			if info == nil {
+				t.Fatal("got nil")
+			}
+			if info.Name != tt.wantName || info.Pod != tt.wantPod {
+				t.Errorf("got %+v, want name %q pod %q", info, tt.wantName, tt.wantPod)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+//go:build linux
+
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// proxyTarget is the forwarding set up for one docker-proxy process.
+type proxyTarget struct {
+	Proto         string
+	HostIP        string
+	HostPort      int
+	ContainerIP   string
+	ContainerPort int
+}
+
+// detectDockerProxy resolves a rootful Docker userland proxy to the
+// container it forwards to. docker-proxy lives in the host cgroup, so
+// parseCgroup can't see the container; its command line can.
+func (s *Snapshot) detectDockerProxy(pid int) *Info {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return nil
+	}
+	args := strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+	target, ok := parseDockerProxyArgs(args)
+	if !ok {
+		return nil
+	}
+	if e := s.byIP("docker", target.ContainerIP); e != nil {
+		return s.info("docker", e)
+	}
+	return s.byPort(target.HostPort, "docker")
+}
+
+// parseDockerProxyArgs extracts the forwarding from a docker-proxy command
+// line, e.g. "docker-proxy -proto tcp -host-ip 0.0.0.0 -host-port 5432
+// -container-ip 172.17.0.2 -container-port 5432". Both "-flag value" and
+// "-flag=value" forms are accepted.
+func parseDockerProxyArgs(args []string) (proxyTarget, bool) {
+	if len(args) == 0 || filepath.Base(args[0]) != "docker-proxy" {
+		return proxyTarget{}, false
+	}
+	var t proxyTarget
+	for i := 1; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !hasValue {
+			if i+1 >= len(args) {
+				break
+			}
+			i++
+			value = args[i]
+		}
+		switch name {
+		case "proto":
+			t.Proto = value
+		case "host-ip":
+			t.HostIP = value
+		case "host-port":
+			t.HostPort, _ = strconv.Atoi(value)
+		case "container-ip":
+			t.ContainerIP = value
+		case "container-port":
+			t.ContainerPort, _ = strconv.Atoi(value)
+		}
+	}
+	return t, t.ContainerIP != "" && t.HostPort > 0
+}
@@ -0,0 +1,52 @@
+//go:build linux
+
+package container
+
+import "testing"
+
+func TestParseDockerProxyArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		want   proxyTarget
+		wantOK bool
+	}{
+		{
+			name: "separate values",
+			args: []string{"/usr/bin/docker-proxy", "-proto", "tcp", "-host-ip", "0.0.0.0",
+				"-host-port", "5432", "-container-ip", "172.17.0.2", "-container-port", "5432"},
+			want:   proxyTarget{Proto: "tcp", HostIP: "0.0.0.0", HostPort: 5432, ContainerIP: "172.17.0.2", ContainerPort: 5432},
+			wantOK: true,
+		},
+		{
+			name: "equals form",
+			args: []string{"docker-proxy", "--proto=udp", "--host-ip=::", "--host-port=53",
+				"--container-ip=fd00::2", "--container-port=5353"},
+			want:   proxyTarget{Proto: "udp", HostIP: "::", HostPort: 53, ContainerIP: "fd00::2", ContainerPort: 5353},
+			wantOK: true,
+		},
+		{
+			name: "other binary",
+			args: []string{"/usr/bin/socat", "-container-ip", "172.17.0.2", "-host-port", "80"},
+		},
+		{
+			name: "missing container ip",
+			args: []string{"docker-proxy", "-proto", "tcp", "-host-port", "80"},
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseDockerProxyArgs(tt.args)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("target = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,438 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Snapshot lists each runtime's running containers at most once and
+// answers every lookup from that listing. Share one Snapshot across a
+// refresh so detecting many processes costs one API call (or CLI run) per
+// runtime instead of one `inspect` per process.
+type Snapshot struct {
+	mu       sync.Mutex
+	lists    map[string][]entry
+	policies *restartPolicies
+}
+
+// NewSnapshot returns an empty Snapshot; runtimes are listed on first use.
+func NewSnapshot() *Snapshot {
+	return &Snapshot{
+		lists:    make(map[string][]entry),
+		policies: &restartPolicies{byID: make(map[string]*policyLookup)},
+	}
+}
+
+// Refresh returns an empty Snapshot that keeps the restart policies s has
+// inspected, so a listing refreshed every few seconds inspects each
+// running container once.
+func (s *Snapshot) Refresh() *Snapshot {
+	return &Snapshot{lists: make(map[string][]entry), policies: s.policies}
+}
+
+// Detect checks if a process is running inside a container, or forwards
+// a port for one. Returns nil if the process is not containerized.
+func Detect(pid, port int) *Info {
+	return NewSnapshot().Detect(pid, port)
+}
+
+// entry is one running container as listed by a runtime.
+type entry struct {
+	ID         string
+	Name       string
+	Pod        string // pod name, set only for a Podman pod's infra container
+	IPs        []string
+	Ports      []portRange // published host ports
+	Labels     map[string]string
+	Kubernetes *KubernetesPod
+	// RestartPolicy is only included in some listings; restartKnown tells
+	// whether it still has to be inspected.
+	RestartPolicy string
+	restartKnown  bool
+	Paused        bool
+}
+
+type portRange struct {
+	first, last int
+}
+
+func (e entry) publishes(port int) bool {
+	for _, r := range e.Ports {
+		if port >= r.first && port <= r.last {
+			return true
+		}
+	}
+	return false
+}
+
+// info describes e as a container of runtime, including its compose
+// membership.
+func (s *Snapshot) info(runtime string, e *entry) *Info {
+	return &Info{
+		ID:         e.ID,
+		Name:       e.Name,
+		Runtime:    runtime,
+		Pod:        e.Pod,
+		Kubernetes: e.Kubernetes,
+		Compose:    s.compose(runtime, e),
+		Paused:     e.Paused,
+
+		RestartPolicy: s.restartPolicy(runtime, e),
+	}
+}
+
+// restartPolicy returns e's restart policy, inspecting the container the
+// first time it is needed by this or an earlier Snapshot. The inspect runs
+// without holding s.mu, so detecting other containers doesn't wait on it;
+// concurrent callers for the same container share one inspect.
+func (s *Snapshot) restartPolicy(runtime string, e *entry) string {
+	if e.restartKnown {
+		return e.RestartPolicy
+	}
+	l := s.policies.lookup(runtime, e.ID)
+	l.once.Do(func() { l.policy = inspectRestartPolicy(runtime, e.ID) })
+	return l.policy
+}
+
+// restartPolicies caches inspected restart policies by runtime and
+// container ID across the Snapshots of one Refresh chain.
+type restartPolicies struct {
+	mu   sync.Mutex
+	byID map[string]*policyLookup
+}
+
+// policyLookup is the inspect of one container's restart policy.
+type policyLookup struct {
+	once   sync.Once
+	policy string
+}
+
+// lookup returns the lookup of runtime's container id, adding it on first
+// use.
+func (p *restartPolicies) lookup(runtime, id string) *policyLookup {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := runtime + "/" + id
+	l := p.byID[key]
+	if l == nil {
+		l = new(policyLookup)
+		p.byID[key] = l
+	}
+	return l
+}
+
+// keep forgets the policies of runtime's containers missing from list.
+// A stopped container may get a new policy, e.g. from DisableRestart,
+// before it is started again.
+func (p *restartPolicies) keep(runtime string, list []entry) {
+	running := make(map[string]bool, len(list))
+	for _, e := range list {
+		running[runtime+"/"+e.ID] = true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key := range p.byID {
+		if strings.HasPrefix(key, runtime+"/") && !running[key] {
+			delete(p.byID, key)
+		}
+	}
+}
+
+// inspectRestartPolicy asks the runtime's API, or its CLI when no socket
+// is reachable or the API answers with an error, for a container's restart
+// policy.
+func inspectRestartPolicy(runtime, containerID string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), detectionTimeout)
+	defer cancel()
+	if c := newAPIClient(runtime); c != nil {
+		if policy, err := c.restartPolicy(ctx, containerID); err == nil {
+			return policy
+		}
+	}
+	out, err := exec.CommandContext(ctx, runtime, "inspect", "--format", "{{.HostConfig.RestartPolicy.Name}}", containerID).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// matchesID reports whether id names this container, allowing either side
+// to be a short ID.
+func (e entry) matchesID(id string) bool {
+	return id != "" && (strings.HasPrefix(e.ID, id) || strings.HasPrefix(id, e.ID))
+}
+
+// containers returns the running containers of runtime, listing them on
+// first use.
+func (s *Snapshot) containers(runtime string) []entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if list, ok := s.lists[runtime]; ok {
+		return list
+	}
+	list := listContainers(runtime)
+	s.lists[runtime] = list
+	s.policies.keep(runtime, list)
+	return list
+}
+
+// nodeContainers returns the CRI containers running inside node, listing
+// them on first use.
+func (s *Snapshot) nodeContainers(node *KubernetesNode) []entry {
+	key := crictl + "@" + node.ID
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if list, ok := s.lists[key]; ok {
+		return list
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), detectionTimeout)
+	defer cancel()
+	list, _ := listCRIContainers(ctx, node)
+	s.lists[key] = list
+	return list
+}
+
+// byID returns the container of runtime with the given ID.
+func (s *Snapshot) byID(runtime, id string) *entry {
+	return findID(s.containers(runtime), id)
+}
+
+// criByID returns the CRI container with the given ID, asking crictl
+// inside node when the container runs in one.
+func (s *Snapshot) criByID(node *KubernetesNode, id string) *entry {
+	if node == nil {
+		return s.byID(crictl, id)
+	}
+	if node.Runtime == "" {
+		return nil
+	}
+	return findID(s.nodeContainers(node), id)
+}
+
+// findID returns the container in list with the given ID.
+func findID(list []entry, id string) *entry {
+	for i := range list {
+		if list[i].matchesID(id) {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+// byIP returns the container of runtime that has ip on any network.
+func (s *Snapshot) byIP(runtime, ip string) *entry {
+	list := s.containers(runtime)
+	for i := range list {
+		for _, addr := range list[i].IPs {
+			if addr == ip {
+				return &list[i]
+			}
+		}
+	}
+	return nil
+}
+
+// byPort asks each runtime, in order, for a running container that
+// publishes the given host port.
+func (s *Snapshot) byPort(port int, runtimes ...string) *Info {
+	for _, runtime := range runtimes {
+		list := s.containers(runtime)
+		for i := range list {
+			if list[i].publishes(port) {
+				return s.info(runtime, &list[i])
+			}
+		}
+	}
+	return nil
+}
+
+// listContainers lists the running containers of runtime through its API
+// socket, falling back to the CLI when no socket is reachable or the API
+// answers with an error.
+func listContainers(runtime string) []entry {
+	ctx, cancel := context.WithTimeout(context.Background(), detectionTimeout)
+	defer cancel()
+	if c := newAPIClient(runtime); c != nil {
+		if list, err := c.listContainers(ctx); err == nil {
+			return list
+		}
+	}
+	list, _ := listContainersCLI(ctx, runtime)
+	return list
+}
+
+// listContainersCLI lists containers with `podman ps`, `crictl ps`, or
+// `docker ps` plus a single `docker inspect` for networks and ports.
+func listContainersCLI(ctx context.Context, runtime string) ([]entry, error) {
+	if runtime == crictl {
+		return listCRIContainers(ctx, nil)
+	}
+	if _, err := exec.LookPath(runtime); err != nil {
+		return nil, err
+	}
+	if runtime == "podman" {
+		out, err := exec.CommandContext(ctx, "podman", "ps", "--format", "json").Output()
+		if err != nil {
+			return nil, err
+		}
+		var list []podmanContainer
+		if err := json.Unmarshal(out, &list); err != nil {
+			return nil, err
+		}
+		return podmanEntries(list), nil
+	}
+
+	ids, err := exec.CommandContext(ctx, runtime, "ps", "-q", "--no-trunc").Output()
+	if err != nil || len(strings.Fields(string(ids))) == 0 {
+		return nil, err
+	}
+	args := append([]string{"inspect"}, strings.Fields(string(ids))...)
+	out, err := exec.CommandContext(ctx, runtime, args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	var list []dockerInspect
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, err
+	}
+	return inspectEntries(list), nil
+}
+
+type dockerNetwork struct {
+	IPAddress         string `json:"IPAddress"`
+	GlobalIPv6Address string `json:"GlobalIPv6Address"`
+}
+
+func (n dockerNetwork) ips() []string {
+	var ips []string
+	for _, ip := range []string{n.IPAddress, n.GlobalIPv6Address} {
+		if ip != "" {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+// dockerContainer is one element of the Engine API's GET /containers/json.
+type dockerContainer struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Labels map[string]string `json:"Labels"`
+	State  string            `json:"State"` // "running", "paused", ...
+	Ports  []struct {
+		PublicPort int `json:"PublicPort"`
+	} `json:"Ports"`
+	NetworkSettings struct {
+		Networks map[string]dockerNetwork `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+func dockerEntries(list []dockerContainer) []entry {
+	entries := make([]entry, len(list))
+	for i, c := range list {
+		e := entry{ID: c.ID, Labels: c.Labels, Paused: c.State == "paused"}
+		if len(c.Names) > 0 {
+			// Docker prefixes names with /
+			e.Name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		for _, p := range c.Ports {
+			if p.PublicPort > 0 {
+				e.Ports = append(e.Ports, portRange{p.PublicPort, p.PublicPort})
+			}
+		}
+		for _, n := range c.NetworkSettings.Networks {
+			e.IPs = append(e.IPs, n.ips()...)
+		}
+		entries[i] = e
+	}
+	return entries
+}
+
+type hostConfig struct {
+	RestartPolicy struct {
+		Name string `json:"Name"`
+	} `json:"RestartPolicy"`
+}
+
+// dockerInspect is one element of `docker inspect` output.
+type dockerInspect struct {
+	ID     string `json:"Id"`
+	Name   string `json:"Name"`
+	Config struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+	HostConfig hostConfig `json:"HostConfig"`
+	State      struct {
+		Paused bool `json:"Paused"`
+	} `json:"State"`
+	NetworkSettings struct {
+		Ports map[string][]struct {
+			HostPort string `json:"HostPort"`
+		} `json:"Ports"`
+		Networks map[string]dockerNetwork `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+func inspectEntries(list []dockerInspect) []entry {
+	entries := make([]entry, len(list))
+	for i, c := range list {
+		e := entry{
+			ID:            c.ID,
+			Name:          strings.TrimPrefix(c.Name, "/"),
+			Labels:        c.Config.Labels,
+			RestartPolicy: c.HostConfig.RestartPolicy.Name,
+			restartKnown:  true,
+			Paused:        c.State.Paused,
+		}
+		for _, bindings := range c.NetworkSettings.Ports {
+			for _, b := range bindings {
+				if p, err := strconv.Atoi(b.HostPort); err == nil && p > 0 {
+					e.Ports = append(e.Ports, portRange{p, p})
+				}
+			}
+		}
+		for _, n := range c.NetworkSettings.Networks {
+			e.IPs = append(e.IPs, n.ips()...)
+		}
+		entries[i] = e
+	}
+	return entries
+}
+
+// podmanContainer is one element of `podman ps --format json` and of the
+// libpod API's GET /libpod/containers/json, which share a format.
+type podmanContainer struct {
+	ID      string            `json:"Id"`
+	Names   []string          `json:"Names"`
+	Labels  map[string]string `json:"Labels"`
+	PodName string            `json:"PodName"`
+	IsInfra bool              `json:"IsInfra"`
+	State   string            `json:"State"`
+	Ports   []struct {
+		HostPort int `json:"host_port"`
+		Range    int `json:"range"`
+	} `json:"Ports"`
+}
+
+func podmanEntries(list []podmanContainer) []entry {
+	entries := make([]entry, len(list))
+	for i, c := range list {
+		e := entry{ID: c.ID, Labels: c.Labels, Paused: c.State == "paused"}
+		if len(c.Names) > 0 {
+			e.Name = c.Names[0]
+		}
+		// Ports published by a pod's infra container belong to the whole pod
+		if c.IsInfra {
+			e.Pod = c.PodName
+		}
+		for _, p := range c.Ports {
+			e.Ports = append(e.Ports, portRange{p.HostPort, p.HostPort + max(p.Range, 1) - 1})
+		}
+		entries[i] = e
+	}
+	return entries
+}
@@ -0,0 +1,83 @@
+package container
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestInspectEntries(t *testing.T) {
+	data := `[{
+	  "Id": "` + testID + `", "Name": "/db",
+	  "HostConfig": {"RestartPolicy": {"Name": "unless-stopped"}},
+	  "State": {"Status": "paused", "Paused": true},
+	  "NetworkSettings": {
+	    "Ports": {"5432/tcp": [{"HostIp": "0.0.0.0", "HostPort": "5432"}, {"HostIp": "::", "HostPort": "5432"}],
+	              "9187/tcp": null},
+	    "Networks": {"app": {"IPAddress": "172.18.0.5", "GlobalIPv6Address": "fd00::5"}}
+	  }
+	}]`
+	var list []dockerInspect
+	if err := json.Unmarshal([]byte(data), &list); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	want := []entry{{
+		ID:    testID,
+		Name:  "db",
+		IPs:   []string{"172.18.0.5", "fd00::5"},
+		Ports: []portRange{{5432, 5432}, {5432, 5432}},
+
+		RestartPolicy: "unless-stopped",
+		restartKnown:  true,
+		Paused:        true,
+	}}
+	if got := inspectEntries(list); !reflect.DeepEqual(got, want) {
+		t.Errorf("inspectEntries = %+v, want %+v", got, want)
+	}
+}
+
+func TestEntryMatchesID(t *testing.T) {
+	e := entry{ID: testID}
+	for id, want := range map[string]bool{
+		testID:         true,
+		testID[:12]:    true,
+		"":             false,
+		"ffffffffffff": false,
+	} {
+		if got := e.matchesID(id); got != want {
+			t.Errorf("matchesID(%q) = %v, want %v", id, got, want)
+		}
+	}
+
+	short := entry{ID: testID[:12]}
+	if !short.matchesID(testID) {
+		t.Error("short listing ID should match a full ID")
+	}
+}
+
+func TestSnapshotCompose(t *testing.T) {
+	s := NewSnapshot()
+	s.lists["docker"] = []entry{
+		{ID: "a1", Name: "shop-api-1", Labels: map[string]string{composeProjectLabel: "shop", composeServiceLabel: "api"}, restartKnown: true},
+		{ID: "a2", Name: "shop-api-2", Labels: map[string]string{composeProjectLabel: "shop", composeServiceLabel: "api"}, restartKnown: true},
+		{ID: "d1", Name: "shop-db-1", Labels: map[string]string{composeProjectLabel: "shop", composeServiceLabel: "db"}, restartKnown: true},
+		{ID: "x1", Name: "other-api-1", Labels: map[string]string{composeProjectLabel: "other", composeServiceLabel: "api"}, restartKnown: true},
+		{ID: "solo", Name: "solo", restartKnown: true},
+	}
+
+	info := s.info("docker", s.byID("docker", "d1"))
+	want := &Compose{
+		Project:           "shop",
+		Service:           "db",
+		ServiceContainers: []string{"d1"},
+		ProjectContainers: []string{"a1", "a2", "d1"},
+	}
+	if !reflect.DeepEqual(info.Compose, want) {
+		t.Errorf("Compose = %+v, want %+v", info.Compose, want)
+	}
+
+	if info := s.info("docker", s.byID("docker", "solo")); info.Compose != nil {
+		t.Errorf("Compose for a plain container = %+v, want nil", info.Compose)
+	}
+}
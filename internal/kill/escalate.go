@@ -0,0 +1,160 @@
+package kill
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+
+	"github.com/dnlvgl/zap/internal/port"
+	"github.com/dnlvgl/zap/internal/process"
+)
+
+// DefaultGracePeriod is how long an escalating action waits after the
+// graceful step before escalating to a forceful kill.
+const DefaultGracePeriod = 5 * time.Second
+
+// forcefulTimeout bounds the wait after the final, forceful step.
+const forcefulTimeout = 5 * time.Second
+
+var pollInterval = 200 * time.Millisecond
+
+// detectPort is swapped out in tests.
+var detectPort = port.Detect
+
+// Result reports which step of an action freed the target's ports.
+type Result struct {
+	Step    string        // Describe() text of the step that freed the ports
+	Elapsed time.Duration // time from the first step until the ports were free
+	// PortsKept is set for actions that leave the ports held, like restart
+	// and pause; Elapsed then only covers running the step.
+	PortsKept bool
+}
+
+// String returns a short human-readable summary, e.g. "freed by kill -SIGTERM 1234 in 1.2s".
+func (r Result) String() string {
+	if r.PortsKept {
+		return fmt.Sprintf("ran %s in %s", r.Step, r.Elapsed.Round(100*time.Millisecond))
+	}
+	return fmt.Sprintf("freed by %s in %s", r.Step, r.Elapsed.Round(100*time.Millisecond))
+}
+
+// gracePeriod returns the action's grace period or the default.
+func (a Action) gracePeriod() time.Duration {
+	if a.GracePeriod > 0 {
+		return a.GracePeriod
+	}
+	return DefaultGracePeriod
+}
+
+// forcefulStep returns the action to escalate to once the grace period has
+// passed. ok is false when the action is already forceful.
+func forcefulStep(action Action) (Action, bool) {
+	if action.Force || (action.Strategy == StrategySignal && action.signal() == syscall.SIGKILL) {
+		return Action{}, false
+	}
+	// rm -f is already forceful; restart, pause, resume and socket units
+	// have no forceful variant
+	switch action.Strategy {
+	case StrategyRestart, StrategyPause, StrategyResume, StrategyRemove, StrategySocket:
+		return Action{}, false
+	}
+	step := action
+	step.Escalate = false
+	step.Force = true
+	step.Signal = 0
+	// systemctl stop has no forceful variant; SIGKILL the main process
+	if action.Strategy == StrategySystemd {
+		step.Strategy = StrategySignal
+	}
+	return step, true
+}
+
+// ExecuteAndVerify performs the action and waits until the process has exited
+// and its ports are no longer listed by port.Detect. With action.Escalate set,
+// it escalates to a forceful kill after the grace period. Restart and pause
+// keep the ports, so they return as soon as the runtime has acted.
+func ExecuteAndVerify(action Action) (Result, error) {
+	start := time.Now()
+
+	first := action
+	first.Escalate = false
+	if err := Execute(first); err != nil {
+		return Result{}, err
+	}
+	if action.Strategy.keepsPorts() {
+		return Result{Step: Describe(first), Elapsed: time.Since(start), PortsKept: true}, nil
+	}
+
+	next, canEscalate := forcefulStep(action)
+	if !action.Escalate || !canEscalate {
+		if !waitReleased(action, action.gracePeriod()) {
+			return Result{}, fmt.Errorf("%s did not free the port within %s", Describe(first), action.gracePeriod())
+		}
+		return Result{Step: Describe(first), Elapsed: time.Since(start)}, nil
+	}
+
+	if waitReleased(action, action.gracePeriod()) {
+		return Result{Step: Describe(first), Elapsed: time.Since(start)}, nil
+	}
+
+	if err := Execute(next); err != nil {
+		return Result{}, fmt.Errorf("escalating to %s: %w", Describe(next), err)
+	}
+	if !waitReleased(action, forcefulTimeout) {
+		return Result{}, fmt.Errorf("%s did not free the port within %s", Describe(next), forcefulTimeout)
+	}
+	return Result{Step: Describe(next), Elapsed: time.Since(start)}, nil
+}
+
+// waitReleased polls until the target has released its ports or timeout passes.
+func waitReleased(action Action, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if released(action) {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// released reports whether the target process has exited and none of its
+// ports are still held. For containers only the ports are checked, since
+// the listener PID may belong to the runtime's proxy (e.g. on macOS), and
+// likewise for socket units, whose PID is the service manager.
+func released(action Action) bool {
+	ctx := action.Context
+	if !action.Strategy.stopsContainer() && !ctx.IsSocketUnit() && process.IsRunning(ctx.Info.PID) {
+		return false
+	}
+	for _, b := range ctx.Info.Ports {
+		listeners, err := detectPort(port.Query{StartPort: b.Port, EndPort: b.Port})
+		if err != nil {
+			return false
+		}
+		for _, l := range listeners {
+			if l.Protocol == b.Protocol && l.Interface == b.Interface {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// stopsContainer reports whether the strategy acts through the container
+// runtime rather than on the listening process itself.
+func (s Strategy) stopsContainer() bool {
+	switch s {
+	case StrategyContainer, StrategyStopNoRestart, StrategyComposeService, StrategyComposeProject, StrategyRemove:
+		return true
+	}
+	return false
+}
+
+// keepsPorts reports whether the strategy leaves the target listening, so
+// there is nothing to wait for after running it.
+func (s Strategy) keepsPorts() bool {
+	return s == StrategyRestart || s == StrategyPause || s == StrategyResume
+}
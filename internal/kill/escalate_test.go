@@ -0,0 +1,311 @@
+package kill
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dnlvgl/zap/internal/port"
+	"github.com/dnlvgl/zap/internal/process"
+)
+
+// startProcess starts a shell command and reaps it in the background.
+// It waits until the shell has exec'd into sleep so signal dispositions
+// set by the script are in place.
+func startProcess(t *testing.T, script string) int {
+	t.Helper()
+	cmd := exec.Command("sh", "-c", script)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	go func() { _ = cmd.Wait() }()
+	t.Cleanup(func() { _ = cmd.Process.Kill() })
+
+	pid := cmd.Process.Pid
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if info, err := process.Gather(pid); err == nil && strings.HasPrefix(info.Command, "sleep") {
+			return pid
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("process %d never exec'd sleep", pid)
+	return 0
+}
+
+// stubDetect replaces port detection for the duration of a test.
+func stubDetect(t *testing.T, fn func(port.Query) ([]port.Listener, error)) {
+	t.Helper()
+	origDetect, origPoll := detectPort, pollInterval
+	t.Cleanup(func() { detectPort, pollInterval = origDetect, origPoll })
+	detectPort = fn
+	pollInterval = 10 * time.Millisecond
+}
+
+func TestExecuteAndVerify(t *testing.T) {
+	stubDetect(t, func(port.Query) ([]port.Listener, error) { return nil, nil })
+
+	tests := []struct {
+		name     string
+		script   string
+		escalate bool
+		wantStep string
+		wantErr  bool
+	}{
+		{"exits on SIGTERM", "exec sleep 30", true, "SIGTERM", false},
+		{"ignores SIGTERM, escalates", "trap '' TERM; exec sleep 30", true, "SIGKILL", false},
+		{"ignores SIGTERM, no escalation", "trap '' TERM; exec sleep 30", false, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pid := startProcess(t, tt.script)
+			action := Action{
+				Strategy:    StrategySignal,
+				Context:     process.Context{Info: process.Info{PID: pid}},
+				Escalate:    tt.escalate,
+				GracePeriod: 300 * time.Millisecond,
+			}
+
+			result, err := ExecuteAndVerify(action)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(result.Step, tt.wantStep) {
+				t.Errorf("Step = %q, want it to contain %q", result.Step, tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestPauseAndResume(t *testing.T) {
+	pid := startProcess(t, "exec sleep 30")
+	ctx := process.Context{Info: process.Info{PID: pid}}
+
+	stopped := func(want bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			info, err := process.Gather(pid)
+			if err == nil && info.Stopped == want {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Stopped = %v, want %v", info.Stopped, want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	result, err := ExecuteAndVerify(Action{Strategy: StrategyPause, Context: ctx})
+	if err != nil || !result.PortsKept {
+		t.Fatalf("pause: %+v, %v", result, err)
+	}
+	stopped(true)
+
+	ctx.Info.Stopped, ctx.Frozen = true, true
+	if _, err := ExecuteAndVerify(Action{Strategy: StrategyResume, Context: ctx}); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	stopped(false)
+}
+
+func TestExecuteTree(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "sleep 30 & sleep 30 & wait")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	done := make(chan struct{})
+	go func() { _ = cmd.Wait(); close(done) }()
+	t.Cleanup(func() { _ = cmd.Process.Kill() })
+
+	pid := cmd.Process.Pid
+	var children []process.Node
+	deadline := time.Now().Add(2 * time.Second)
+	for len(children) < 2 && time.Now().Before(deadline) {
+		children = process.Descendants(pid)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(children) < 2 {
+		t.Fatalf("Descendants(%d) = %+v, want two sleeps", pid, children)
+	}
+
+	action := Action{Strategy: StrategyTree, Context: process.Context{Info: process.Info{PID: pid}}, Force: true}
+	if err := Execute(action); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("shell still running")
+	}
+	for _, c := range children {
+		deadline := time.Now().Add(2 * time.Second)
+		for process.IsRunning(c.PID) && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if process.IsRunning(c.PID) {
+			t.Errorf("child %d still running", c.PID)
+		}
+	}
+}
+
+func TestExecuteGroup(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "sleep 30 & wait")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	done := make(chan struct{})
+	go func() { _ = cmd.Wait(); close(done) }()
+	t.Cleanup(func() { _ = cmd.Process.Kill() })
+
+	pid := cmd.Process.Pid
+	var members []process.Node
+	deadline := time.Now().Add(2 * time.Second)
+	for len(members) < 2 && time.Now().Before(deadline) {
+		members = process.GroupMembers(pid)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(members) < 2 {
+		t.Fatalf("GroupMembers(%d) = %+v, want the shell and its sleep", pid, members)
+	}
+
+	action := Action{Strategy: StrategyGroup, Context: process.Context{Info: process.Info{PID: pid, PGID: pid}}, Force: true}
+	if err := Execute(action); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("shell still running")
+	}
+	for _, m := range members {
+		deadline := time.Now().Add(2 * time.Second)
+		for process.IsRunning(m.PID) && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if process.IsRunning(m.PID) {
+			t.Errorf("group member %d still running", m.PID)
+		}
+	}
+
+	own := Action{Strategy: StrategyGroup, Context: process.Context{Info: process.Info{PID: 2, PGID: syscall.Getpgrp()}}}
+	if err := Execute(own); err == nil {
+		t.Error("expected Execute to refuse zap's own process group")
+	}
+}
+
+func TestExecuteRefusesReusedPID(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	t.Cleanup(func() { _ = cmd.Process.Kill(); _ = cmd.Wait() })
+
+	info, err := process.Gather(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The row was listed for a process that started an hour earlier
+	info.StartTime = info.StartTime.Add(-time.Hour)
+	for _, s := range []Strategy{StrategySignal, StrategyTree, StrategyGroup, StrategyPause} {
+		action := Action{Strategy: s, Context: process.Context{Info: info}, Force: true}
+		if err := Execute(action); !errors.Is(err, process.ErrReused) {
+			t.Errorf("Execute(%s) = %v, want ErrReused", s, err)
+		}
+	}
+	if !process.IsRunning(info.PID) {
+		t.Error("process was signalled despite the PID check")
+	}
+}
+
+func TestExecuteRefusesExitedTarget(t *testing.T) {
+	// The group leader exits, leaving its sleep behind in the group
+	cmd := exec.Command("sh", "-c", "sleep 30 >/dev/null 2>&1 & echo $!")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	leader := cmd.Process.Pid
+	var sleep int
+	if _, err := fmt.Sscan(string(out), &sleep); err != nil {
+		t.Fatalf("sleep PID %q: %v", out, err)
+	}
+	t.Cleanup(func() { _ = syscall.Kill(sleep, syscall.SIGKILL) })
+
+	info := process.Info{PID: leader, PGID: leader}
+	for _, s := range []Strategy{StrategyCgroup, StrategyTree, StrategyGroup} {
+		action := Action{Strategy: s, Context: process.Context{Info: info}, Force: true}
+		if err := Execute(action); err == nil {
+			t.Errorf("Execute(%s) on an exited target = nil, want an error", s)
+		}
+	}
+	if !process.IsRunning(sleep) {
+		t.Error("the group was signalled after its leader exited")
+	}
+}
+
+func TestReleasedWaitsForPort(t *testing.T) {
+	held := true
+	stubDetect(t, func(q port.Query) ([]port.Listener, error) {
+		if !held {
+			return nil, nil
+		}
+		return []port.Listener{{PID: 1, Port: q.StartPort, Protocol: "tcp", Interface: "0.0.0.0"}}, nil
+	})
+	action := Action{
+		Strategy: StrategyContainer,
+		Context: process.Context{Info: process.Info{
+			Ports: []process.PortBinding{{Port: 5432, Protocol: "tcp", Interface: "0.0.0.0"}},
+		}},
+	}
+
+	if released(action) {
+		t.Error("expected port to be reported as held")
+	}
+	held = false
+	if !released(action) {
+		t.Error("expected port to be reported as released")
+	}
+}
+
+func TestDescribeEscalate(t *testing.T) {
+	action := Action{
+		Strategy:    StrategySignal,
+		Context:     process.Context{Info: process.Info{PID: 1234}},
+		Escalate:    true,
+		GracePeriod: 3 * time.Second,
+	}
+	want := "kill -SIGTERM 1234, then kill -SIGKILL 1234 after 3s"
+	if got := Describe(action); got != want {
+		t.Errorf("Describe() = %q, want %q", got, want)
+	}
+
+	action.Force = true
+	if got := Describe(action); got != "kill -SIGKILL 1234" {
+		t.Errorf("Describe() with force = %q", got)
+	}
+}
+
+func TestResultString(t *testing.T) {
+	freed := Result{Step: "kill -SIGTERM 1234", Elapsed: 1234 * time.Millisecond}
+	if got := freed.String(); got != "freed by kill -SIGTERM 1234 in 1.2s" {
+		t.Errorf("String() = %q", got)
+	}
+	kept := Result{Step: "docker restart db", Elapsed: 2 * time.Second, PortsKept: true}
+	if got := kept.String(); got != "ran docker restart db in 2s" {
+		t.Errorf("String() with PortsKept = %q", got)
+	}
+}
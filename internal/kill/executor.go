@@ -1,9 +1,15 @@
 package kill
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/dnlvgl/zap/internal/cgroup"
 	"github.com/dnlvgl/zap/internal/container"
 	"github.com/dnlvgl/zap/internal/process"
 	"github.com/dnlvgl/zap/internal/systemd"
@@ -13,11 +19,42 @@ import (
 type Strategy int
 
 const (
-	StrategySignal    Strategy = iota // Send SIGTERM or SIGKILL
-	StrategyContainer                 // podman/docker stop or kill
-	StrategySystemd                   // systemctl stop
+	StrategySignal         Strategy = iota // Send SIGTERM or SIGKILL
+	StrategyContainer                      // podman/docker stop or kill
+	StrategySystemd                        // systemctl [--user] stop
+	StrategyComposeService                 // stop every container of the compose service
+	StrategyComposeProject                 // stop every container of the compose project
+	StrategyRestart                        // podman/docker restart
+	StrategyPause                          // podman/docker pause, freeze the cgroup or SIGSTOP
+	StrategyRemove                         // podman/docker rm -f
+	StrategyStopNoRestart                  // disable the restart policy, then stop
+	StrategySocket                         // stop a .socket unit and the services it activates
+	StrategyCgroup                         // signal every process in the cgroup
+	StrategyResume                         // undo StrategyPause
+	StrategyTree                           // signal the process and its descendants
+	StrategyLauncher                       // signal the terminal command that started it, and all below
+	StrategyGroup                          // signal the process group, like Ctrl-C in its terminal
 )
 
+// strategies lists every strategy, in the order ParseStrategy documents them.
+var strategies = []Strategy{
+	StrategyContainer,
+	StrategyStopNoRestart,
+	StrategyComposeService,
+	StrategyComposeProject,
+	StrategyRestart,
+	StrategyPause,
+	StrategyResume,
+	StrategyRemove,
+	StrategySystemd,
+	StrategySocket,
+	StrategyCgroup,
+	StrategyTree,
+	StrategyLauncher,
+	StrategyGroup,
+	StrategySignal,
+}
+
 func (s Strategy) String() string {
 	switch s {
 	case StrategySignal:
@@ -26,6 +63,30 @@ func (s Strategy) String() string {
 		return "container"
 	case StrategySystemd:
 		return "systemd"
+	case StrategyComposeService:
+		return "compose-service"
+	case StrategyComposeProject:
+		return "compose-project"
+	case StrategyRestart:
+		return "restart"
+	case StrategyPause:
+		return "pause"
+	case StrategyRemove:
+		return "remove"
+	case StrategyStopNoRestart:
+		return "stop-no-restart"
+	case StrategySocket:
+		return "socket"
+	case StrategyCgroup:
+		return "cgroup"
+	case StrategyResume:
+		return "resume"
+	case StrategyTree:
+		return "tree"
+	case StrategyLauncher:
+		return "launcher"
+	case StrategyGroup:
+		return "group"
 	default:
 		return "unknown"
 	}
@@ -36,11 +97,22 @@ type Action struct {
 	Strategy Strategy
 	Context  process.Context
 	Force    bool
+	// Signal overrides the signal sent by StrategySignal; zero means
+	// SIGTERM, or SIGKILL when Force is set.
+	Signal syscall.Signal
+	// Escalate makes ExecuteAndVerify follow up with a forceful kill when
+	// the target still holds its ports after GracePeriod.
+	Escalate    bool
+	GracePeriod time.Duration // zero means DefaultGracePeriod
 }
 
 // RecommendedStrategy picks the best strategy for a given process context.
 func RecommendedStrategy(ctx process.Context) Strategy {
-	if ctx.IsContainerized() {
+	// Stopping only the service would let the socket start it again
+	if ctx.IsSocketUnit() || ctx.ActivatedBy != nil {
+		return StrategySocket
+	}
+	if ctx.IsContainerized() && ctx.Container.Reachable() {
 		return StrategyContainer
 	}
 	if ctx.IsSystemdManaged() {
@@ -51,74 +123,472 @@ func RecommendedStrategy(ctx process.Context) Strategy {
 
 // AvailableStrategies returns all applicable strategies for a process context.
 func AvailableStrategies(ctx process.Context) []Strategy {
+	// Signalling the service manager itself is never what's wanted
+	if ctx.IsSocketUnit() {
+		return []Strategy{StrategySocket}
+	}
 	var strategies []Strategy
-	if ctx.IsContainerized() {
+	if ctx.Frozen {
+		strategies = append(strategies, StrategyResume)
+	}
+	// A pod in a node zap cannot exec into can only be signalled
+	if ctx.IsContainerized() && ctx.Container.Reachable() {
 		strategies = append(strategies, StrategyContainer)
+		if c := ctx.Container; c.Restarts() && c.Pod == "" && !container.IsCRI(c.Runtime) {
+			strategies = append(strategies, StrategyStopNoRestart)
+		}
+		if ctx.Container.Compose != nil {
+			strategies = append(strategies, StrategyComposeService, StrategyComposeProject)
+		}
+		// crictl can neither restart nor pause
+		if !container.IsCRI(ctx.Container.Runtime) {
+			strategies = append(strategies, StrategyRestart)
+			if !ctx.Frozen {
+				strategies = append(strategies, StrategyPause)
+			}
+		}
+		strategies = append(strategies, StrategyRemove)
 	}
 	if ctx.IsSystemdManaged() {
 		strategies = append(strategies, StrategySystemd)
 	}
+	if ctx.ActivatedBy != nil {
+		strategies = append(strategies, StrategySocket)
+	}
+	if cgroupKillable(ctx) {
+		strategies = append(strategies, StrategyCgroup)
+	}
+	if len(ctx.Info.Descendants) > 0 {
+		strategies = append(strategies, StrategyTree)
+	}
+	if _, ok := ctx.Info.Launcher(); ok {
+		strategies = append(strategies, StrategyLauncher)
+	}
+	// Containers are stopped through their runtime, and zap's own group
+	// is never a target
+	if !ctx.IsContainerized() && ctx.Info.PGID > 1 && ctx.Info.PGID != syscall.Getpgrp() {
+		strategies = append(strategies, StrategyGroup)
+	}
+	if !ctx.IsContainerized() && !ctx.Frozen {
+		strategies = append(strategies, StrategyPause)
+	}
 	strategies = append(strategies, StrategySignal)
 	return strategies
 }
 
 // Execute performs the kill action.
 func Execute(action Action) error {
+	if action.Context.OwnerUnknown {
+		return fmt.Errorf("owner of the listening socket is unknown, try running with sudo")
+	}
+	// The listing may be seconds old; its PID could name another process by
+	// now. Cgroup, group and tree actions also need the target alive to
+	// vouch for the processes around it
+	if signalsPID(action) {
+		verify := action.Context.Info.Verify
+		switch action.Strategy {
+		case StrategyCgroup, StrategyTree, StrategyLauncher, StrategyGroup:
+			verify = action.Context.Info.VerifyRunning
+		}
+		if err := verify(); err != nil {
+			return err
+		}
+	}
 	switch action.Strategy {
 	case StrategyContainer:
 		return executeContainer(action)
+	case StrategyStopNoRestart:
+		c := action.Context.Container
+		if err := container.DisableRestart(c.ID, c.Runtime); err != nil {
+			return fmt.Errorf("disabling restart policy: %w", err)
+		}
+		return executeContainer(action)
+	case StrategyComposeService, StrategyComposeProject:
+		return executeCompose(action)
+	case StrategyRestart, StrategyRemove:
+		return executeContainerOp(action)
+	case StrategyPause:
+		return executePause(action)
+	case StrategyResume:
+		return executeResume(action)
 	case StrategySystemd:
 		return executeSystemd(action)
+	case StrategySocket:
+		sock := socketOf(action.Context)
+		if sock == nil {
+			return fmt.Errorf("PID %d is not socket-activated", action.Context.Info.PID)
+		}
+		return systemd.StopSocket(*sock)
+	case StrategyCgroup:
+		if action.Context.Cgroup == nil {
+			return fmt.Errorf("no cgroup to kill for PID %d", action.Context.Info.PID)
+		}
+		return cgroup.Kill(action.Context.Cgroup, action.signal())
+	case StrategyTree, StrategyLauncher:
+		return executeTree(action)
+	case StrategyGroup:
+		return executeGroup(action)
 	case StrategySignal:
-		return executeSignal(action)
+		if err := executeSignal(action); err != nil {
+			return err
+		}
+		// A stopped process only acts on the signal once it runs again
+		if wakesFrozen(action) {
+			return executeResume(action)
+		}
+		return nil
 	default:
 		return fmt.Errorf("unknown strategy: %v", action.Strategy)
 	}
 }
 
+// signalsPID reports whether action acts on the target by its PID rather
+// than by a container or unit name.
+func signalsPID(action Action) bool {
+	switch action.Strategy {
+	case StrategyPause, StrategyResume:
+		return !action.Context.IsContainerized()
+	}
+	return action.Strategy.UsesSignal()
+}
+
 // Describe returns a human-readable description of what the action will do.
 func Describe(action Action) string {
+	if action.Context.OwnerUnknown {
+		return ownerUnknownText()
+	}
+	if action.Escalate {
+		if next, ok := forcefulStep(action); ok {
+			first := action
+			first.Escalate = false
+			return fmt.Sprintf("%s, then %s after %s", Describe(first), Describe(next), action.gracePeriod())
+		}
+	}
 	switch action.Strategy {
 	case StrategyContainer:
+		c := action.Context.Container
+		switch {
+		case action.Force && container.IsCRI(c.Runtime):
+			return describeContainerOp(c, "stop --timeout 0")
+		case action.Force:
+			return describeContainerOp(c, "kill")
+		default:
+			return describeContainerOp(c, "stop")
+		}
+	case StrategyStopNoRestart:
+		stop := action
+		stop.Strategy = StrategyContainer
+		return fmt.Sprintf("%s, then %s", describeContainerOp(action.Context.Container, "update --restart=no"), Describe(stop))
+	case StrategyRestart:
+		return describeContainerOp(action.Context.Container, "restart")
+	case StrategyPause:
+		switch pauseMethodOf(action.Context) {
+		case pauseContainer:
+			return describeContainerOp(action.Context.Container, "pause")
+		case pauseCgroup:
+			g := action.Context.Cgroup
+			return fmt.Sprintf("freeze cgroup %s (%s)", g.Path, processCount(len(g.Members)))
+		default:
+			return fmt.Sprintf("kill -SIGSTOP %d", action.Context.Info.PID)
+		}
+	case StrategyResume:
+		switch resumeMethodOf(action.Context) {
+		case pauseContainer:
+			return describeContainerOp(action.Context.Container, "unpause")
+		case pauseCgroup:
+			g := action.Context.Cgroup
+			return fmt.Sprintf("thaw cgroup %s (%s)", g.Path, processCount(len(g.Members)))
+		default:
+			return fmt.Sprintf("kill -SIGCONT %d", action.Context.Info.PID)
+		}
+	case StrategyRemove:
+		return describeContainerOp(action.Context.Container, "rm -f")
+	case StrategyComposeService, StrategyComposeProject:
 		verb := "stop"
 		if action.Force {
 			verb = "kill"
 		}
 		c := action.Context.Container
-		name := c.Name
-		if name == "" {
-			name = container.ShortID(c.ID)
+		if c.Compose == nil {
+			return "unknown action"
+		}
+		what := fmt.Sprintf("compose service %s/%s", c.Compose.Project, c.Compose.Service)
+		ids := c.Compose.ServiceContainers
+		if action.Strategy == StrategyComposeProject {
+			what = fmt.Sprintf("compose project %s", c.Compose.Project)
+			ids = c.Compose.ProjectContainers
 		}
-		return fmt.Sprintf("%s %s %s", c.Runtime, verb, name)
+		return fmt.Sprintf("%s %s %s (%s)", c.Runtime, verb, what, containerCount(len(ids)))
 	case StrategySystemd:
-		return fmt.Sprintf("systemctl stop %s", action.Context.SystemdUnit)
+		return fmt.Sprintf("%s stop %s", action.Context.SystemdScope.Systemctl(), action.Context.SystemdUnit)
+	case StrategySocket:
+		sock := socketOf(action.Context)
+		if sock == nil {
+			return "unknown action"
+		}
+		return fmt.Sprintf("%s stop %s", sock.Scope.Systemctl(), strings.Join(sock.Units(), " "))
+	case StrategyCgroup:
+		g := action.Context.Cgroup
+		if g == nil {
+			return "unknown action"
+		}
+		return fmt.Sprintf("kill -%s cgroup %s (%s)", SignalName(action.signal()), g.Path, processCount(len(g.Members)))
+	case StrategyTree:
+		return fmt.Sprintf("kill -%s %d and its %s", SignalName(action.signal()), action.Context.Info.PID,
+			descendantCount(len(action.Context.Info.Descendants)))
+	case StrategyLauncher:
+		launcher, ok := action.Context.Info.Launcher()
+		if !ok {
+			return "unknown action"
+		}
+		return fmt.Sprintf("kill -%s %d (%s) and its descendants", SignalName(action.signal()), launcher.PID,
+			truncate(launcher.Command, 30))
+	case StrategyGroup:
+		info := action.Context.Info
+		desc := fmt.Sprintf("kill -%s -%d (process group)", SignalName(action.signal()), info.PGID)
+		if leader, ok := groupLeader(info); ok {
+			desc = fmt.Sprintf("kill -%s -%d (process group of %s)", SignalName(action.signal()), info.PGID,
+				truncate(leader, 30))
+		}
+		if wakesFrozen(action) {
+			desc += fmt.Sprintf(", then kill -SIGCONT -%d", info.PGID)
+		}
+		return desc
 	case StrategySignal:
-		sig := "SIGTERM"
-		if action.Force {
-			sig = "SIGKILL"
+		desc := fmt.Sprintf("kill -%s %d", SignalName(action.signal()), action.Context.Info.PID)
+		if wakesFrozen(action) {
+			resume := action
+			resume.Strategy = StrategyResume
+			desc += ", then " + Describe(resume)
 		}
-		return fmt.Sprintf("kill -%s %d", sig, action.Context.Info.PID)
+		return desc
 	default:
 		return "unknown action"
 	}
 }
 
+// ownerUnknownText describes a listener whose process zap cannot see.
+func ownerUnknownText() string {
+	if os.Getuid() == 0 {
+		return "owner unknown"
+	}
+	return "owner unknown — run with sudo"
+}
+
 func executeContainer(action Action) error {
 	c := action.Context.Container
+	if container.IsCRI(c.Runtime) {
+		return container.StopCRI(c.ID, c.Node, action.Force)
+	}
+	if c.Pod != "" {
+		if action.Force {
+			return container.KillPod(c.Pod)
+		}
+		return container.StopPod(c.Pod)
+	}
 	if action.Force {
 		return container.Kill(c.ID, c.Runtime)
 	}
 	return container.Stop(c.ID, c.Runtime)
 }
 
+// describeContainerOp renders "<runtime> <verb> <name>" for a container,
+// or the pod or crictl equivalent.
+func describeContainerOp(c *container.Info, verb string) string {
+	if container.IsCRI(c.Runtime) {
+		if n := c.Node; n != nil {
+			return fmt.Sprintf("%s exec %s crictl %s %s", n.Runtime, n, verb, container.ShortID(c.ID))
+		}
+		return fmt.Sprintf("crictl %s %s", verb, container.ShortID(c.ID))
+	}
+	if c.Pod != "" {
+		return fmt.Sprintf("%s pod %s %s", c.Runtime, verb, c.Pod)
+	}
+	name := c.Name
+	if name == "" {
+		name = container.ShortID(c.ID)
+	}
+	return fmt.Sprintf("%s %s %s", c.Runtime, verb, name)
+}
+
+func executeContainerOp(action Action) error {
+	c := action.Context.Container
+	if c.Pod != "" {
+		switch action.Strategy {
+		case StrategyRestart:
+			return container.RestartPod(c.Pod)
+		case StrategyPause:
+			return container.PausePod(c.Pod)
+		case StrategyResume:
+			return container.UnpausePod(c.Pod)
+		default:
+			return container.RemovePod(c.Pod)
+		}
+	}
+	switch action.Strategy {
+	case StrategyRestart:
+		return container.Restart(c.ID, c.Runtime)
+	case StrategyPause:
+		return container.Pause(c.ID, c.Runtime)
+	case StrategyResume:
+		return container.Unpause(c.ID, c.Runtime)
+	default:
+		if container.IsCRI(c.Runtime) {
+			return container.RemoveCRI(c.ID, c.Node)
+		}
+		return container.Remove(c.ID, c.Runtime)
+	}
+}
+
+// pauseMethod is how StrategyPause and StrategyResume act on a target.
+type pauseMethod int
+
+const (
+	pauseSignal    pauseMethod = iota // SIGSTOP / SIGCONT to the process
+	pauseCgroup                       // freeze / thaw its cgroup
+	pauseContainer                    // podman/docker pause / unpause
+)
+
+// pauseMethodOf picks the most thorough way to pause ctx: the container
+// runtime, then the cgroup freezer, which also stops children, then
+// SIGSTOP. The freezer is only used on the target's own cgroup (see
+// ownCgroup); a terminal tab's scope also holds the shell, which freezing
+// would hang.
+func pauseMethodOf(ctx process.Context) pauseMethod {
+	if c := ctx.Container; c != nil && !container.IsCRI(c.Runtime) {
+		return pauseContainer
+	}
+	if ownCgroup(ctx) {
+		return pauseCgroup
+	}
+	return pauseSignal
+}
+
+// ownCgroup reports whether ctx's cgroup is dedicated to the target: a
+// container's, or that of the service it runs in.
+func ownCgroup(ctx process.Context) bool {
+	g := ctx.Cgroup
+	return g != nil && (ctx.Container != nil || (ctx.IsSystemdManaged() && path.Base(g.Path) == ctx.SystemdUnit))
+}
+
+// cgroupKillable reports whether killing ctx's cgroup takes down only the
+// target and what belongs to it: its own cgroup, or a scope holding nothing
+// but its job. A terminal tab's scope (vte-spawn-*, tmux-spawn-*, app-*)
+// or a terminal server's service also holds the user's shell.
+func cgroupKillable(ctx process.Context) bool {
+	if ownCgroup(ctx) {
+		return true
+	}
+	g := ctx.Cgroup
+	return g != nil && strings.HasSuffix(path.Base(g.Path), ".scope") && g.SingleJob
+}
+
+// resumeMethodOf undoes however ctx was found paused, or else whatever
+// pauseMethodOf would have used.
+func resumeMethodOf(ctx process.Context) pauseMethod {
+	switch {
+	case ctx.Container != nil && ctx.Container.Paused:
+		return pauseContainer
+	case ctx.Cgroup != nil && ctx.Cgroup.Frozen:
+		return pauseCgroup
+	case ctx.Info.Stopped:
+		return pauseSignal
+	}
+	return pauseMethodOf(ctx)
+}
+
+func executePause(action Action) error {
+	switch pauseMethodOf(action.Context) {
+	case pauseContainer:
+		return executeContainerOp(action)
+	case pauseCgroup:
+		return cgroup.Freeze(action.Context.Cgroup)
+	default:
+		stop := action
+		stop.Signal = syscall.SIGSTOP
+		return executeSignal(stop)
+	}
+}
+
+func executeResume(action Action) error {
+	resume := action
+	resume.Strategy = StrategyResume
+	switch resumeMethodOf(action.Context) {
+	case pauseContainer:
+		return executeContainerOp(resume)
+	case pauseCgroup:
+		return cgroup.Thaw(action.Context.Cgroup)
+	default:
+		resume.Signal = syscall.SIGCONT
+		return executeSignal(resume)
+	}
+}
+
+// wakesFrozen reports whether a signal action resumes its frozen target
+// afterwards. Only SIGKILL takes effect while a process is stopped.
+func wakesFrozen(action Action) bool {
+	sig := action.signal()
+	return action.Context.Frozen && sig != syscall.SIGKILL && sig != syscall.SIGSTOP && sig != syscall.SIGCONT
+}
+
+func executeCompose(action Action) error {
+	c := action.Context.Container
+	if c.Compose == nil {
+		return fmt.Errorf("%s is not part of a compose project", c)
+	}
+	ids := c.Compose.ServiceContainers
+	if action.Strategy == StrategyComposeProject {
+		ids = c.Compose.ProjectContainers
+	}
+	return container.StopAll(ids, c.Runtime, action.Force)
+}
+
+func containerCount(n int) string {
+	if n == 1 {
+		return "1 container"
+	}
+	return fmt.Sprintf("%d containers", n)
+}
+
+func descendantCount(n int) string {
+	if n == 1 {
+		return "1 descendant"
+	}
+	return fmt.Sprintf("%d descendants", n)
+}
+
+// truncate shortens s to at most n bytes, marking the cut with "...".
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
+
+func processCount(n int) string {
+	if n == 1 {
+		return "1 process"
+	}
+	return fmt.Sprintf("%d processes", n)
+}
+
+// socketOf returns the socket unit a row stands for or was activated by.
+func socketOf(ctx process.Context) *systemd.Socket {
+	if ctx.SocketUnit != nil {
+		return ctx.SocketUnit
+	}
+	return ctx.ActivatedBy
+}
+
 func executeSystemd(action Action) error {
-	return systemd.Stop(action.Context.SystemdUnit)
+	return systemd.Stop(action.Context.SystemdUnit, action.Context.SystemdScope)
 }
 
 func executeSignal(action Action) error {
-	sig := syscall.SIGTERM
-	if action.Force {
-		sig = syscall.SIGKILL
+	sig := action.signal()
+	// kill(0) or kill(-1) would hit our own process group or every process
+	if action.Context.Info.PID <= 0 {
+		return fmt.Errorf("refusing to signal PID %d", action.Context.Info.PID)
 	}
 	err := action.Context.Info.Signal(sig)
 	if err != nil && action.Context.Info.IsPrivileged() {
@@ -126,3 +596,147 @@ func executeSignal(action Action) error {
 	}
 	return err
 }
+
+// CgroupTargets returns the members of the cgroup a cgroup action
+// signals, or a pause action freezes. It returns nil for other strategies
+// and for pauses that don't use the freezer.
+func CgroupTargets(action Action) []cgroup.Member {
+	g := action.Context.Cgroup
+	if g == nil {
+		return nil
+	}
+	if action.Strategy == StrategyCgroup ||
+		(action.Strategy == StrategyPause && pauseMethodOf(action.Context) == pauseCgroup) {
+		return g.Members
+	}
+	return nil
+}
+
+// TreeTargets returns the processes a tree or launcher action signals, in
+// order: the root, then everything below it as it is now. It returns nil
+// for other strategies.
+func TreeTargets(action Action) []process.Node {
+	info := action.Context.Info
+	var root process.Node
+	switch action.Strategy {
+	case StrategyTree:
+		root = process.Node{PID: info.PID, Command: info.Command, PGID: info.PGID, SID: info.SID, TTY: info.TTY,
+			StartTime: info.StartTime}
+	case StrategyLauncher:
+		launcher, ok := info.Launcher()
+		if !ok {
+			return nil
+		}
+		root = launcher
+	default:
+		return nil
+	}
+	return append([]process.Node{root}, process.Descendants(root.PID)...)
+}
+
+// executeTree signals a process tree top-down, so no parent is left
+// running to restart a child that already got the signal.
+func executeTree(action Action) error {
+	targets := TreeTargets(action)
+	if len(targets) == 0 {
+		return fmt.Errorf("PID %d has no launcher in its terminal session", action.Context.Info.PID)
+	}
+	root := targets[0].PID
+	if root <= 1 {
+		return fmt.Errorf("refusing to signal PID %d", root)
+	}
+	// The launcher comes from the listing; its PID may have exited or been
+	// reused since, so check that it still runs above the verified target
+	if action.Strategy == StrategyLauncher && !isAncestor(root, action.Context.Info.PID) {
+		return fmt.Errorf("launcher PID %d no longer runs above PID %d; refusing to signal it",
+			root, action.Context.Info.PID)
+	}
+	if self, err := process.Gather(os.Getpid()); err == nil {
+		for _, a := range self.Ancestors {
+			if a.PID == root {
+				return fmt.Errorf("refusing to signal PID %d (%s): zap runs below it", root, a.Command)
+			}
+		}
+	}
+	sig := action.signal()
+	var errs []error
+	for i, n := range targets {
+		// Each node is checked against its listed start time, since a PID
+		// freed after the listing may already belong to someone else
+		err := n.Signal(sig)
+		if err == nil || (i > 0 && errors.Is(err, os.ErrProcessDone)) {
+			// A descendant may exit meanwhile, e.g. with its parent
+			continue
+		}
+		if errors.Is(err, os.ErrProcessDone) {
+			err = errors.New("exited before it was signalled")
+		}
+		errs = append(errs, fmt.Errorf("PID %d: %w", n.PID, err))
+	}
+	err := errors.Join(errs...)
+	if err != nil && action.Context.Info.IsPrivileged() {
+		return fmt.Errorf("%w (process owned by %s, try running with sudo)", err, action.Context.Info.User)
+	}
+	return err
+}
+
+// groupLeader returns the command of the process leading info's process
+// group, when it is the process itself or one of its ancestors.
+func groupLeader(info process.Info) (string, bool) {
+	if info.PGID == info.PID {
+		return info.Command, true
+	}
+	for _, a := range info.Ancestors {
+		if a.PID == info.PGID {
+			return a.Command, true
+		}
+	}
+	return "", false
+}
+
+// isAncestor reports whether ancestor currently runs above pid.
+func isAncestor(ancestor, pid int) bool {
+	info, err := process.Gather(pid)
+	if err != nil {
+		return false
+	}
+	for _, a := range info.Ancestors {
+		if a.PID == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupTargets returns the members of a group action's process group as
+// they are now. It returns nil for other strategies.
+func GroupTargets(action Action) []process.Node {
+	if action.Strategy != StrategyGroup || action.Context.Info.PGID <= 1 {
+		return nil
+	}
+	return process.GroupMembers(action.Context.Info.PGID)
+}
+
+// executeGroup signals the target's process group with kill(-pgid), which
+// is what Ctrl-C in the terminal that started it does.
+func executeGroup(action Action) error {
+	pgid := action.Context.Info.PGID
+	// kill(-1) would hit every process; kill(-0) does not exist
+	if pgid <= 1 {
+		return fmt.Errorf("refusing to signal process group %d", pgid)
+	}
+	if pgid == syscall.Getpgrp() {
+		return fmt.Errorf("refusing to signal process group %d: zap runs in it", pgid)
+	}
+	err := syscall.Kill(-pgid, action.signal())
+	if err == nil && wakesFrozen(action) {
+		err = syscall.Kill(-pgid, syscall.SIGCONT)
+	}
+	if err != nil {
+		err = fmt.Errorf("process group %d: %w", pgid, err)
+		if action.Context.Info.IsPrivileged() {
+			return fmt.Errorf("%w (process owned by %s, try running with sudo)", err, action.Context.Info.User)
+		}
+	}
+	return err
+}
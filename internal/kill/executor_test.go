@@ -1,10 +1,16 @@
 package kill
 
 import (
+	"os"
+	"reflect"
+	"slices"
+	"syscall"
 	"testing"
 
+	"github.com/dnlvgl/zap/internal/cgroup"
 	"github.com/dnlvgl/zap/internal/container"
 	"github.com/dnlvgl/zap/internal/process"
+	"github.com/dnlvgl/zap/internal/systemd"
 )
 
 func TestRecommendedStrategy(t *testing.T) {
@@ -26,6 +32,14 @@ func TestRecommendedStrategy(t *testing.T) {
 			},
 			want: StrategyContainer,
 		},
+		{
+			name: "pod in unreachable node",
+			ctx: process.Context{
+				Info:      process.Info{PID: 1234},
+				Container: &container.Info{ID: "abc", Runtime: container.RuntimeContainerd, Node: &container.KubernetesNode{ID: "f00d"}},
+			},
+			want: StrategySignal,
+		},
 		{
 			name: "systemd process",
 			ctx: process.Context{
@@ -43,6 +57,23 @@ func TestRecommendedStrategy(t *testing.T) {
 			},
 			want: StrategyContainer,
 		},
+		{
+			name: "socket unit",
+			ctx: process.Context{
+				Info:       process.Info{PID: 1},
+				SocketUnit: &systemd.Socket{Unit: "sshd.socket", Activates: []string{"sshd.service"}},
+			},
+			want: StrategySocket,
+		},
+		{
+			name: "socket-activated service",
+			ctx: process.Context{
+				Info:        process.Info{PID: 1234},
+				SystemdUnit: "sshd.service",
+				ActivatedBy: &systemd.Socket{Unit: "sshd.socket", Activates: []string{"sshd.service"}},
+			},
+			want: StrategySocket,
+		},
 	}
 
 	for _, tt := range tests {
@@ -62,18 +93,188 @@ func TestAvailableStrategies(t *testing.T) {
 		SystemdUnit: "myapp.service",
 	}
 
-	strategies := AvailableStrategies(ctx)
-	if len(strategies) != 3 {
-		t.Fatalf("expected 3 strategies, got %d", len(strategies))
+	got := AvailableStrategies(ctx)
+	want := []Strategy{StrategyContainer, StrategyRestart, StrategyPause, StrategyRemove, StrategySystemd, StrategySignal}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AvailableStrategies() = %v, want %v", got, want)
+	}
+
+	ctx.Container.RestartPolicy = "unless-stopped"
+	got = AvailableStrategies(ctx)
+	want = []Strategy{StrategyContainer, StrategyStopNoRestart, StrategyRestart, StrategyPause, StrategyRemove, StrategySystemd, StrategySignal}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AvailableStrategies(restart policy) = %v, want %v", got, want)
+	}
+
+	cri := process.Context{
+		Info:      process.Info{PID: 1234},
+		Container: &container.Info{ID: "abc", Runtime: container.RuntimeContainerd},
+	}
+	got = AvailableStrategies(cri)
+	want = []Strategy{StrategyContainer, StrategyRemove, StrategySignal}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AvailableStrategies(cri) = %v, want %v", got, want)
+	}
+
+	cri.Container.Node = &container.KubernetesNode{ID: "f00d"}
+	got = AvailableStrategies(cri)
+	want = []Strategy{StrategySignal}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AvailableStrategies(unreachable node) = %v, want %v", got, want)
+	}
+
+	sshd := &systemd.Socket{Unit: "sshd.socket", Activates: []string{"sshd.service"}}
+	got = AvailableStrategies(process.Context{Info: process.Info{PID: 1}, SocketUnit: sshd})
+	want = []Strategy{StrategySocket}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AvailableStrategies(socket unit) = %v, want %v", got, want)
+	}
+
+	got = AvailableStrategies(process.Context{Info: process.Info{PID: 1234}, SystemdUnit: "sshd.service", ActivatedBy: sshd})
+	want = []Strategy{StrategySystemd, StrategySocket, StrategyPause, StrategySignal}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AvailableStrategies(socket-activated) = %v, want %v", got, want)
+	}
+
+	got = AvailableStrategies(process.Context{
+		Info:        process.Info{PID: 1234},
+		SystemdUnit: "nginx.service",
+		Cgroup:      nginxCgroup(),
+	})
+	want = []Strategy{StrategySystemd, StrategyCgroup, StrategyPause, StrategySignal}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AvailableStrategies(cgroup) = %v, want %v", got, want)
+	}
+
+	got = AvailableStrategies(process.Context{Info: process.Info{PID: 1234, Stopped: true}, Frozen: true})
+	want = []Strategy{StrategyResume, StrategySignal}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AvailableStrategies(frozen) = %v, want %v", got, want)
+	}
+
+	got = AvailableStrategies(process.Context{
+		Info:      process.Info{PID: 1234},
+		Container: &container.Info{ID: "abc", Runtime: "docker", Paused: true},
+		Frozen:    true,
+	})
+	want = []Strategy{StrategyResume, StrategyContainer, StrategyRestart, StrategyRemove, StrategySignal}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AvailableStrategies(paused container) = %v, want %v", got, want)
+	}
+}
+
+// viteInfo is a dev server started from a terminal by `npm run dev`.
+func viteInfo() process.Info {
+	return process.Info{
+		PID: 4133, Command: "node vite", PGID: 4120, SID: 4100, TTY: "pts/1",
+		Ancestors: []process.Node{
+			{PID: 4120, Command: "npm run dev", PGID: 4120, SID: 4100, TTY: "pts/1"},
+			{PID: 4100, Command: "zsh", PGID: 4100, SID: 4100, TTY: "pts/1"},
+		},
+		Descendants: []process.Node{
+			{PID: 4140, Command: "esbuild", Depth: 1},
+			{PID: 4141, Command: "esbuild", Depth: 2},
+		},
+	}
+}
+
+func TestAvailableStrategiesTree(t *testing.T) {
+	got := AvailableStrategies(process.Context{Info: viteInfo()})
+	want := []Strategy{StrategyTree, StrategyLauncher, StrategyGroup, StrategyPause, StrategySignal}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AvailableStrategies() = %v, want %v", got, want)
+	}
+}
+
+func nginxCgroup() *cgroup.Group {
+	return &cgroup.Group{
+		Path:    "/system.slice/nginx.service",
+		Members: []cgroup.Member{{PID: 1234, Command: "nginx"}, {PID: 1240, Command: "nginx"}},
 	}
-	if strategies[0] != StrategyContainer {
-		t.Errorf("first strategy = %v, want container", strategies[0])
+}
+
+// terminalCgroup is the scope of a terminal tab, holding its shell too.
+func terminalCgroup() *cgroup.Group {
+	return &cgroup.Group{
+		Path:    "/user.slice/user-1000.slice/user@1000.service/app.slice/app-org.gnome.Terminal.slice/vte-spawn-1.scope",
+		Members: []cgroup.Member{{PID: 1200, Command: "bash"}, {PID: 1234, Command: "node"}},
 	}
-	if strategies[1] != StrategySystemd {
-		t.Errorf("second strategy = %v, want systemd", strategies[1])
+}
+
+func TestCgroupTargets(t *testing.T) {
+	ctx := process.Context{Info: process.Info{PID: 1234}, Cgroup: nginxCgroup(), SystemdUnit: "nginx.service"}
+	for _, tt := range []struct {
+		strategy Strategy
+		want     int
+	}{
+		{StrategyCgroup, 2},
+		{StrategyPause, 2},
+		{StrategySignal, 0},
+		{StrategyTree, 0},
+	} {
+		if got := CgroupTargets(Action{Strategy: tt.strategy, Context: ctx}); len(got) != tt.want {
+			t.Errorf("CgroupTargets(%s) = %v, want %d members", tt.strategy, got, tt.want)
+		}
 	}
-	if strategies[2] != StrategySignal {
-		t.Errorf("third strategy = %v, want signal", strategies[2])
+
+	// Pausing a process in a terminal tab's scope stops only the process
+	tab := process.Context{Info: process.Info{PID: 1234}, Cgroup: terminalCgroup()}
+	if got := CgroupTargets(Action{Strategy: StrategyPause, Context: tab}); len(got) != 0 {
+		t.Errorf("CgroupTargets(pause in a terminal scope) = %v, want none", got)
+	}
+}
+
+func TestAvailableStrategiesCgroup(t *testing.T) {
+	job := terminalCgroup()
+	job.Path = "/user.slice/user-1000.slice/session-2.scope"
+	job.SingleJob = true
+	footServer := nginxCgroup()
+	footServer.Path = "/user.slice/user-1000.slice/user@1000.service/app.slice/foot-server.service"
+
+	tests := []struct {
+		name string
+		ctx  process.Context
+		want bool
+	}{
+		{"own service", process.Context{SystemdUnit: "nginx.service", Cgroup: nginxCgroup()}, true},
+		{"container", process.Context{Container: &container.Info{ID: "abc", Runtime: container.RuntimeContainerd}, Cgroup: nginxCgroup()}, true},
+		{"scope of the target's job only", process.Context{Cgroup: job}, true},
+		{"terminal tab scope", process.Context{Cgroup: terminalCgroup()}, false},
+		{"terminal server service", process.Context{Cgroup: footServer}, false},
+	}
+	for _, tt := range tests {
+		tt.ctx.Info = process.Info{PID: 1234}
+		got := slices.Contains(AvailableStrategies(tt.ctx), StrategyCgroup)
+		if got != tt.want {
+			t.Errorf("%s: cgroup offered = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func composeContainer() *container.Info {
+	return &container.Info{
+		ID:      "a1",
+		Name:    "shop-api-1",
+		Runtime: "docker",
+		Compose: &container.Compose{
+			Project:           "shop",
+			Service:           "api",
+			ServiceContainers: []string{"a1", "a2"},
+			ProjectContainers: []string{"a1", "a2", "db1"},
+		},
+	}
+}
+
+func TestAvailableStrategiesCompose(t *testing.T) {
+	ctx := process.Context{Info: process.Info{PID: 1234}, Container: composeContainer()}
+	got := AvailableStrategies(ctx)
+	want := []Strategy{StrategyContainer, StrategyComposeService, StrategyComposeProject,
+		StrategyRestart, StrategyPause, StrategyRemove, StrategySignal}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AvailableStrategies() = %v, want %v", got, want)
+	}
+	if RecommendedStrategy(ctx) != StrategyContainer {
+		t.Errorf("RecommendedStrategy() = %v, want container", RecommendedStrategy(ctx))
 	}
 }
 
@@ -121,6 +322,116 @@ func TestDescribe(t *testing.T) {
 			},
 			want: "docker kill myapp",
 		},
+		{
+			name: "cri stop",
+			action: Action{
+				Strategy: StrategyContainer,
+				Context: process.Context{
+					Container: &container.Info{ID: "abc123def4567890", Name: "nginx", Runtime: container.RuntimeContainerd},
+				},
+			},
+			want: "crictl stop abc123def456",
+		},
+		{
+			name: "cri kill",
+			action: Action{
+				Strategy: StrategyContainer,
+				Context: process.Context{
+					Container: &container.Info{ID: "abc123def4567890", Name: "nginx", Runtime: container.RuntimeCRIO},
+				},
+				Force: true,
+			},
+			want: "crictl stop --timeout 0 abc123def456",
+		},
+		{
+			name: "cri stop in kind node",
+			action: Action{
+				Strategy: StrategyContainer,
+				Context: process.Context{
+					Container: &container.Info{ID: "abc123def4567890", Name: "nginx", Runtime: container.RuntimeContainerd,
+						Node: &container.KubernetesNode{ID: "f00d", Name: "kind-control-plane", Runtime: "docker"}},
+				},
+			},
+			want: "docker exec kind-control-plane crictl stop abc123def456",
+		},
+		{
+			name: "compose service stop",
+			action: Action{
+				Strategy: StrategyComposeService,
+				Context:  process.Context{Container: composeContainer()},
+			},
+			want: "docker stop compose service shop/api (2 containers)",
+		},
+		{
+			name: "compose project kill",
+			action: Action{
+				Strategy: StrategyComposeProject,
+				Context:  process.Context{Container: composeContainer()},
+				Force:    true,
+			},
+			want: "docker kill compose project shop (3 containers)",
+		},
+		{
+			name: "stop without restart",
+			action: Action{
+				Strategy: StrategyStopNoRestart,
+				Context: process.Context{
+					Container: &container.Info{ID: "abc123def456", Name: "db", Runtime: "docker", RestartPolicy: "always"},
+				},
+			},
+			want: "docker update --restart=no db, then docker stop db",
+		},
+		{
+			name: "container restart",
+			action: Action{
+				Strategy: StrategyRestart,
+				Context: process.Context{
+					Container: &container.Info{ID: "abc123def456", Name: "db", Runtime: "docker"},
+				},
+			},
+			want: "docker restart db",
+		},
+		{
+			name: "pod pause",
+			action: Action{
+				Strategy: StrategyPause,
+				Context: process.Context{
+					Container: &container.Info{ID: "abc123def456", Runtime: "podman", Pod: "stack"},
+				},
+			},
+			want: "podman pod pause stack",
+		},
+		{
+			name: "container remove ignores escalate",
+			action: Action{
+				Strategy: StrategyRemove,
+				Context: process.Context{
+					Container: &container.Info{ID: "abc123def456", Name: "db", Runtime: "podman"},
+				},
+				Escalate: true,
+			},
+			want: "podman rm -f db",
+		},
+		{
+			name: "cri remove",
+			action: Action{
+				Strategy: StrategyRemove,
+				Context: process.Context{
+					Container: &container.Info{ID: "abc123def4567890", Runtime: container.RuntimeCRIO},
+				},
+			},
+			want: "crictl rm -f abc123def456",
+		},
+		{
+			name: "pod stop",
+			action: Action{
+				Strategy: StrategyContainer,
+				Context: process.Context{
+					Container: &container.Info{ID: "abc123def456", Name: "3f2a-infra", Runtime: "podman", Pod: "stack"},
+				},
+			},
+			want: "podman pod stop stack",
+		},
 		{
 			name: "systemd stop",
 			action: Action{
@@ -129,6 +440,154 @@ func TestDescribe(t *testing.T) {
 			},
 			want: "systemctl stop nginx.service",
 		},
+		{
+			name: "systemd user unit",
+			action: Action{
+				Strategy: StrategySystemd,
+				Context: process.Context{
+					SystemdUnit:  "syncthing.service",
+					SystemdScope: systemd.Scope{User: true, UID: os.Getuid()},
+				},
+			},
+			want: "systemctl --user stop syncthing.service",
+		},
+		{
+			name: "socket unit",
+			action: Action{
+				Strategy: StrategySocket,
+				Context: process.Context{
+					Info:       process.Info{PID: 1},
+					SocketUnit: &systemd.Socket{Unit: "cups.socket", Activates: []string{"cups.service"}},
+				},
+			},
+			want: "systemctl stop cups.socket cups.service",
+		},
+		{
+			name: "socket-activated user service",
+			action: Action{
+				Strategy: StrategySocket,
+				Context: process.Context{
+					SystemdUnit: "pipewire.service",
+					ActivatedBy: &systemd.Socket{
+						Unit:      "pipewire.socket",
+						Scope:     systemd.Scope{User: true, UID: os.Getuid()},
+						Activates: []string{"pipewire.service"},
+					},
+				},
+			},
+			want: "systemctl --user stop pipewire.socket pipewire.service",
+		},
+		{
+			name: "pause with SIGSTOP",
+			action: Action{
+				Strategy: StrategyPause,
+				Context:  process.Context{Info: process.Info{PID: 1234}},
+			},
+			want: "kill -SIGSTOP 1234",
+		},
+		{
+			name: "pause with the cgroup freezer",
+			action: Action{
+				Strategy: StrategyPause,
+				Context:  process.Context{Info: process.Info{PID: 1234}, Cgroup: nginxCgroup(), SystemdUnit: "nginx.service"},
+			},
+			want: "freeze cgroup /system.slice/nginx.service (2 processes)",
+		},
+		{
+			name: "pause in a terminal scope",
+			action: Action{
+				Strategy: StrategyPause,
+				Context:  process.Context{Info: process.Info{PID: 1234}, Cgroup: terminalCgroup()},
+			},
+			want: "kill -SIGSTOP 1234",
+		},
+		{
+			name: "resume stopped process",
+			action: Action{
+				Strategy: StrategyResume,
+				Context:  process.Context{Info: process.Info{PID: 1234, Stopped: true}, Cgroup: nginxCgroup(), Frozen: true},
+			},
+			want: "kill -SIGCONT 1234",
+		},
+		{
+			name: "resume paused container",
+			action: Action{
+				Strategy: StrategyResume,
+				Context: process.Context{
+					Container: &container.Info{ID: "abc123def456", Name: "db", Runtime: "docker", Paused: true},
+					Frozen:    true,
+				},
+			},
+			want: "docker unpause db",
+		},
+		{
+			name: "signal wakes a stopped process",
+			action: Action{
+				Strategy: StrategySignal,
+				Context:  process.Context{Info: process.Info{PID: 1234, Stopped: true}, Frozen: true},
+			},
+			want: "kill -SIGTERM 1234, then kill -SIGCONT 1234",
+		},
+		{
+			name: "SIGKILL needs no wake-up",
+			action: Action{
+				Strategy: StrategySignal,
+				Context:  process.Context{Info: process.Info{PID: 1234, Stopped: true}, Frozen: true},
+				Force:    true,
+			},
+			want: "kill -SIGKILL 1234",
+		},
+		{
+			name: "listener and descendants",
+			action: Action{
+				Strategy: StrategyTree,
+				Context:  process.Context{Info: viteInfo()},
+			},
+			want: "kill -SIGTERM 4133 and its 2 descendants",
+		},
+		{
+			name: "terminal launcher",
+			action: Action{
+				Strategy: StrategyLauncher,
+				Context:  process.Context{Info: viteInfo()},
+				Force:    true,
+			},
+			want: "kill -SIGKILL 4120 (npm run dev) and its descendants",
+		},
+		{
+			name: "process group",
+			action: Action{
+				Strategy: StrategyGroup,
+				Context:  process.Context{Info: viteInfo()},
+				Signal:   syscall.SIGINT,
+			},
+			want: "kill -SIGINT -4120 (process group of npm run dev)",
+		},
+		{
+			name: "stopped process group",
+			action: Action{
+				Strategy: StrategyGroup,
+				Context:  process.Context{Info: process.Info{PID: 900, PGID: 880, Stopped: true}, Frozen: true},
+			},
+			want: "kill -SIGTERM -880 (process group), then kill -SIGCONT -880",
+		},
+		{
+			name: "cgroup",
+			action: Action{
+				Strategy: StrategyCgroup,
+				Context:  process.Context{Info: process.Info{PID: 1234}, Cgroup: nginxCgroup()},
+			},
+			want: "kill -SIGTERM cgroup /system.slice/nginx.service (2 processes)",
+		},
+		{
+			name: "cgroup SIGKILL",
+			action: Action{
+				Strategy: StrategyCgroup,
+				Context:  process.Context{Info: process.Info{PID: 1234}, Cgroup: nginxCgroup()},
+				Force:    true,
+			},
+			want: "kill -SIGKILL cgroup /system.slice/nginx.service (2 processes)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -152,3 +611,16 @@ func TestStrategyString(t *testing.T) {
 		t.Errorf("StrategySystemd.String() = %q", s)
 	}
 }
+
+func TestOwnerUnknown(t *testing.T) {
+	action := Action{
+		Strategy: StrategySignal,
+		Context:  process.Context{OwnerUnknown: true},
+	}
+	if got := Describe(action); got != ownerUnknownText() {
+		t.Errorf("Describe() = %q, want %q", got, ownerUnknownText())
+	}
+	if err := Execute(action); err == nil {
+		t.Error("expected Execute to refuse an owner-unknown listener")
+	}
+}
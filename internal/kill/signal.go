@@ -0,0 +1,110 @@
+package kill
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/dnlvgl/zap/internal/process"
+)
+
+// Signals lists the signals offered for the signal strategy, in the order
+// the confirm dialog cycles through them.
+var Signals = []syscall.Signal{
+	syscall.SIGTERM,
+	syscall.SIGINT,
+	syscall.SIGHUP,
+	syscall.SIGQUIT,
+	syscall.SIGUSR1,
+	syscall.SIGKILL,
+}
+
+var signalNames = map[syscall.Signal]string{
+	syscall.SIGTERM: "SIGTERM",
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGHUP:  "SIGHUP",
+	syscall.SIGQUIT: "SIGQUIT",
+	syscall.SIGUSR1: "SIGUSR1",
+	syscall.SIGUSR2: "SIGUSR2",
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGSTOP: "SIGSTOP",
+	syscall.SIGCONT: "SIGCONT",
+}
+
+// SignalName returns the conventional name of a signal, e.g. "SIGTERM".
+func SignalName(sig syscall.Signal) string {
+	if name, ok := signalNames[sig]; ok {
+		return name
+	}
+	return strconv.Itoa(int(sig))
+}
+
+// ParseSignal parses a signal given as "SIGINT", "INT", "int" or "2".
+// Numbers must name a signal the system has, e.g. 1 to 64 on Linux.
+func ParseSignal(s string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 || n > maxSignal {
+			return 0, fmt.Errorf("unknown signal %d (want 1-%d)", n, maxSignal)
+		}
+		return syscall.Signal(n), nil
+	}
+	name := strings.ToUpper(s)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	for sig, n := range signalNames {
+		if n == name {
+			return sig, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown signal %q", s)
+}
+
+// UsesSignal reports whether the strategy sends Action.Signal, so that
+// picking a signal matters for it.
+func (s Strategy) UsesSignal() bool {
+	switch s {
+	case StrategySignal, StrategyCgroup, StrategyTree, StrategyLauncher, StrategyGroup:
+		return true
+	}
+	return false
+}
+
+// ParseStrategy parses a strategy name as printed by Strategy.String.
+func ParseStrategy(s string) (Strategy, error) {
+	names := make([]string, len(strategies))
+	for i, strategy := range strategies {
+		if strings.EqualFold(s, strategy.String()) {
+			return strategy, nil
+		}
+		names[i] = strategy.String()
+	}
+	return 0, fmt.Errorf("unknown strategy %q (want %s)", s, strings.Join(names, ", "))
+}
+
+// ChooseStrategy returns preferred if it applies to ctx, otherwise the
+// recommended strategy. ok is false when preferred had to be replaced.
+// A nil preferred always yields the recommended strategy.
+func ChooseStrategy(ctx process.Context, preferred *Strategy) (strategy Strategy, ok bool) {
+	if preferred == nil {
+		return RecommendedStrategy(ctx), true
+	}
+	for _, s := range AvailableStrategies(ctx) {
+		if s == *preferred {
+			return s, true
+		}
+	}
+	return RecommendedStrategy(ctx), false
+}
+
+// signal returns the signal the signal strategy sends for this action.
+func (a Action) signal() syscall.Signal {
+	if a.Signal != 0 {
+		return a.Signal
+	}
+	if a.Force {
+		return syscall.SIGKILL
+	}
+	return syscall.SIGTERM
+}
@@ -0,0 +1,6 @@
+//go:build darwin
+
+package kill
+
+// maxSignal is the highest signal number, SIGUSR2 (NSIG - 1).
+const maxSignal = 31
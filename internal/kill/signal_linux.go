@@ -0,0 +1,6 @@
+//go:build linux
+
+package kill
+
+// maxSignal is SIGRTMAX, the highest signal number the kernel accepts.
+const maxSignal = 64
@@ -0,0 +1,104 @@
+package kill
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/dnlvgl/zap/internal/container"
+	"github.com/dnlvgl/zap/internal/process"
+)
+
+func TestParseSignal(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    syscall.Signal
+		wantErr bool
+	}{
+		{"SIGINT", syscall.SIGINT, false},
+		{"INT", syscall.SIGINT, false},
+		{"hup", syscall.SIGHUP, false},
+		{"sigusr1", syscall.SIGUSR1, false},
+		{"9", syscall.SIGKILL, false},
+		{"31", syscall.Signal(31), false},
+		{"0", 0, true},
+		{"-9", 0, true},
+		{"999", 0, true},
+		{"SIGBOGUS", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSignal(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseSignal(%q) expected error, got %v", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSignal(%q) unexpected error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSignal(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStrategy(t *testing.T) {
+	for _, s := range strategies {
+		got, err := ParseStrategy(s.String())
+		if err != nil || got != s {
+			t.Errorf("ParseStrategy(%q) = %v, %v", s.String(), got, err)
+		}
+	}
+	if _, err := ParseStrategy("nuke"); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+}
+
+func TestChooseStrategy(t *testing.T) {
+	bare := process.Context{Info: process.Info{PID: 1234}}
+	containerized := process.Context{
+		Info:      process.Info{PID: 1234},
+		Container: &container.Info{ID: "abc", Runtime: "docker"},
+	}
+	signal, containerStrategy := StrategySignal, StrategyContainer
+
+	if got, ok := ChooseStrategy(containerized, nil); got != StrategyContainer || !ok {
+		t.Errorf("ChooseStrategy(nil) = %v, %v", got, ok)
+	}
+	if got, ok := ChooseStrategy(containerized, &signal); got != StrategySignal || !ok {
+		t.Errorf("ChooseStrategy(signal) = %v, %v", got, ok)
+	}
+	if got, ok := ChooseStrategy(bare, &containerStrategy); got != StrategySignal || ok {
+		t.Errorf("ChooseStrategy(container) on bare process = %v, %v, want fallback", got, ok)
+	}
+}
+
+func TestDescribeSignal(t *testing.T) {
+	action := Action{
+		Strategy: StrategySignal,
+		Context:  process.Context{Info: process.Info{PID: 1234}},
+		Signal:   syscall.SIGINT,
+	}
+	if got := Describe(action); got != "kill -SIGINT 1234" {
+		t.Errorf("Describe() = %q", got)
+	}
+
+	action.Escalate = true
+	want := "kill -SIGINT 1234, then kill -SIGKILL 1234 after 5s"
+	if got := Describe(action); got != want {
+		t.Errorf("Describe() escalate = %q, want %q", got, want)
+	}
+}
+
+func TestUsesSignal(t *testing.T) {
+	for _, s := range strategies {
+		want := s == StrategySignal || s == StrategyCgroup || s == StrategyTree || s == StrategyLauncher || s == StrategyGroup
+		if got := s.UsesSignal(); got != want {
+			t.Errorf("%s.UsesSignal() = %v, want %v", s, got, want)
+		}
+	}
+}
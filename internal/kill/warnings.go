@@ -0,0 +1,74 @@
+package kill
+
+import (
+	"fmt"
+
+	"github.com/dnlvgl/zap/internal/container"
+)
+
+// Warnings returns caveats about action that the user should see before
+// confirming it, such as the target coming straight back.
+func Warnings(action Action) []string {
+	ctx := action.Context
+	var warnings []string
+	if c := ctx.Container; c != nil {
+		switch {
+		case !c.Reachable():
+			warnings = append(warnings, fmt.Sprintf("runs in Kubernetes node %s, but neither docker nor podman can reach it; kubelet will restart it",
+				container.ShortID(c.Node.ID)))
+		case container.IsCRI(c.Runtime) && c.Kubernetes != nil:
+			warnings = append(warnings, fmt.Sprintf("kubelet will restart it (pod %s)", c.Kubernetes))
+		case container.IsCRI(c.Runtime):
+			warnings = append(warnings, "kubelet will restart it")
+		case c.Restarts() && action.Strategy == StrategySignal:
+			warnings = append(warnings, fmt.Sprintf("%s will restart it (restart policy %s); use %s",
+				c.Runtime, c.RestartPolicy, restartProofStrategy(action)))
+		case c.RestartPolicy == "always" && action.Strategy.stopsContainer() &&
+			action.Strategy != StrategyStopNoRestart && action.Strategy != StrategyRemove:
+			warnings = append(warnings, fmt.Sprintf("comes back when %s restarts (restart policy always); use %s",
+				c.Runtime, restartProofStrategy(action)))
+		}
+	}
+	if sock := ctx.ActivatedBy; sock != nil && action.Strategy != StrategySocket {
+		warnings = append(warnings, fmt.Sprintf("%s starts it again on the next connection; use %s",
+			sock.Unit, StrategySocket))
+	} else if ctx.IsSystemdManaged() && (action.Strategy == StrategySignal || action.Strategy == StrategyCgroup) &&
+		unitRestarts(ctx.SystemdRestart) {
+		verb := "may"
+		if ctx.SystemdRestart == "always" {
+			verb = "will"
+		}
+		warnings = append(warnings, fmt.Sprintf("systemd %s restart it (Restart=%s); use %s",
+			verb, ctx.SystemdRestart, StrategySystemd))
+	}
+	if action.Strategy == StrategySignal {
+		if launcher, ok := ctx.Info.Launcher(); ok {
+			warnings = append(warnings, fmt.Sprintf("started by %s (PID %d), which may start it again; use %s",
+				truncate(launcher.Command, 30), launcher.PID, StrategyLauncher))
+		}
+		if n := len(ctx.Info.Descendants); n > 0 {
+			warnings = append(warnings, fmt.Sprintf("leaves its %s running; use %s", descendantCount(n), StrategyTree))
+		}
+	}
+	if action.Strategy == StrategyPause && pauseMethodOf(ctx) == pauseSignal && len(ctx.Info.Children) > 0 {
+		warnings = append(warnings, fmt.Sprintf("SIGSTOP leaves its %d child processes running", len(ctx.Info.Children)))
+	}
+	return warnings
+}
+
+// restartProofStrategy names the strategy that stops action's container
+// for good.
+func restartProofStrategy(action Action) Strategy {
+	for _, s := range AvailableStrategies(action.Context) {
+		if s == StrategyStopNoRestart {
+			return s
+		}
+	}
+	return StrategyRemove
+}
+
+// unitRestarts reports whether a Restart= setting restarts the unit after
+// its main process is killed by a signal.
+func unitRestarts(setting string) bool {
+	return setting != "" && setting != "no"
+}
@@ -0,0 +1,150 @@
+package kill
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dnlvgl/zap/internal/container"
+	"github.com/dnlvgl/zap/internal/process"
+	"github.com/dnlvgl/zap/internal/systemd"
+)
+
+func TestWarnings(t *testing.T) {
+	always := &container.Info{ID: "abc", Name: "db", Runtime: "docker", RestartPolicy: "always"}
+	onFailure := &container.Info{ID: "abc", Name: "db", Runtime: "docker", RestartPolicy: "on-failure"}
+
+	tests := []struct {
+		name     string
+		ctx      process.Context
+		strategy *Strategy
+		want     []string
+	}{
+		{
+			name:     "signal on restart=always container",
+			ctx:      process.Context{Container: always},
+			strategy: ptr(StrategySignal),
+			want:     []string{"docker will restart it (restart policy always); use stop-no-restart"},
+		},
+		{
+			name: "stop on restart=always container",
+			ctx:  process.Context{Container: always},
+			want: []string{"comes back when docker restarts (restart policy always); use stop-no-restart"},
+		},
+		{
+			name:     "stop-no-restart on restart=always container",
+			ctx:      process.Context{Container: always},
+			strategy: ptr(StrategyStopNoRestart),
+		},
+		{
+			name: "stop on restart=on-failure container",
+			ctx:  process.Context{Container: onFailure},
+		},
+		{
+			name:     "signal on Restart=always unit",
+			ctx:      process.Context{SystemdUnit: "web.service", SystemdRestart: "always"},
+			strategy: ptr(StrategySignal),
+			want:     []string{"systemd will restart it (Restart=always); use systemd"},
+		},
+		{
+			name:     "cgroup kill on Restart=always unit",
+			ctx:      process.Context{SystemdUnit: "web.service", SystemdRestart: "always"},
+			strategy: ptr(StrategyCgroup),
+			want:     []string{"systemd will restart it (Restart=always); use systemd"},
+		},
+		{
+			name:     "signal on Restart=on-failure unit",
+			ctx:      process.Context{SystemdUnit: "web.service", SystemdRestart: "on-failure"},
+			strategy: ptr(StrategySignal),
+			want:     []string{"systemd may restart it (Restart=on-failure); use systemd"},
+		},
+		{
+			name: "systemctl stop on socket-activated unit",
+			ctx: process.Context{
+				SystemdUnit:    "cups.service",
+				SystemdRestart: "on-failure",
+				ActivatedBy:    &systemd.Socket{Unit: "cups.socket", Activates: []string{"cups.service"}},
+			},
+			strategy: ptr(StrategySystemd),
+			want:     []string{"cups.socket starts it again on the next connection; use socket"},
+		},
+		{
+			name: "socket on socket-activated unit",
+			ctx: process.Context{
+				SystemdUnit: "cups.service",
+				ActivatedBy: &systemd.Socket{Unit: "cups.socket", Activates: []string{"cups.service"}},
+			},
+		},
+		{
+			name: "systemctl stop on Restart=always unit",
+			ctx:  process.Context{SystemdUnit: "web.service", SystemdRestart: "always"},
+		},
+		{
+			name: "bare process",
+			ctx:  process.Context{Info: process.Info{PID: 1234}},
+		},
+		{
+			name:     "signal to a process started by npm",
+			ctx:      process.Context{Info: viteInfo()},
+			strategy: ptr(StrategySignal),
+			want: []string{
+				"started by npm run dev (PID 4120), which may start it again; use launcher",
+				"leaves its 2 descendants running; use tree",
+			},
+		},
+		{
+			name:     "tree kill",
+			ctx:      process.Context{Info: viteInfo()},
+			strategy: ptr(StrategyTree),
+		},
+		{
+			name:     "SIGSTOP with children",
+			ctx:      process.Context{Info: process.Info{PID: 1234, Children: []int{1240, 1241}}},
+			strategy: ptr(StrategyPause),
+			want:     []string{"SIGSTOP leaves its 2 child processes running"},
+		},
+		{
+			name: "docker container",
+			ctx:  process.Context{Container: &container.Info{ID: "abc", Runtime: "docker"}},
+		},
+		{
+			name: "kubernetes pod",
+			ctx: process.Context{Container: &container.Info{
+				ID:         "abc",
+				Runtime:    container.RuntimeContainerd,
+				Kubernetes: &container.KubernetesPod{Namespace: "shop", Name: "web-0"},
+			}},
+			want: []string{"kubelet will restart it (pod shop/web-0)"},
+		},
+		{
+			name: "unresolved cri container",
+			ctx:  process.Context{Container: &container.Info{ID: "abc", Runtime: container.RuntimeCRI}},
+			want: []string{"kubelet will restart it"},
+		},
+		{
+			name: "pod in unreachable node",
+			ctx: process.Context{Container: &container.Info{
+				ID:      "abc",
+				Runtime: container.RuntimeContainerd,
+				Node:    &container.KubernetesNode{ID: "f00d"},
+			}},
+			want: []string{"runs in Kubernetes node f00d, but neither docker nor podman can reach it; kubelet will restart it"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy := RecommendedStrategy(tt.ctx)
+			if tt.strategy != nil {
+				strategy = *tt.strategy
+			}
+			got := Warnings(Action{Strategy: strategy, Context: tt.ctx})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Warnings() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -1,9 +1,45 @@
 package port
 
 // Listener represents a process listening on a port.
+// PID is 0 when the socket's owner could not be determined, typically
+// because it belongs to another user and zap runs unprivileged.
 type Listener struct {
 	PID       int
 	Port      int
 	Protocol  string // "tcp", "tcp6", "udp", "udp6"
 	Interface string // parsed from local address
+	UID       int    // socket owner (Linux only)
+}
+
+// OwnerKnown returns true if the listening process was identified.
+func (l Listener) OwnerKnown() bool {
+	return l.PID != 0
+}
+
+// GroupByPID groups listeners by owning process, preserving the order in
+// which each PID first appears. Exact duplicates (e.g. from overlapping
+// queries) are dropped. Owner-unknown listeners each get their own group,
+// since there is no way to tell whether they share a process.
+func GroupByPID(listeners []Listener) [][]Listener {
+	index := make(map[int]int)
+	seen := make(map[Listener]bool)
+	var groups [][]Listener
+	for _, l := range listeners {
+		if seen[l] {
+			continue
+		}
+		seen[l] = true
+		if !l.OwnerKnown() {
+			groups = append(groups, []Listener{l})
+			continue
+		}
+		i, ok := index[l.PID]
+		if !ok {
+			i = len(groups)
+			index[l.PID] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], l)
+	}
+	return groups
 }
@@ -9,6 +9,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -17,16 +18,30 @@ type socketInfo struct {
 	port     int
 	protocol string
 	iface    string
+	uid      int
 }
 
 // Detect finds all processes listening on ports matching the query.
 func Detect(q Query) ([]Listener, error) {
-	return detectFromProc(q)
+	return detect(q)
 }
 
 // DetectAll finds all listening processes across all ports.
 func DetectAll() ([]Listener, error) {
-	return detectFromProc(Query{StartPort: 1, EndPort: 65535})
+	return detect(Query{StartPort: 1, EndPort: 65535})
+}
+
+// detect prefers the netlink sock_diag backend and falls back to parsing
+// /proc/net when netlink is unavailable (old kernels, restricted sandboxes).
+func detect(q Query) ([]Listener, error) {
+	inodeMap, err := socketsFromNetlink(q)
+	if err != nil {
+		return detectFromProc(q)
+	}
+	if len(inodeMap) == 0 {
+		return nil, nil
+	}
+	return findPIDsForInodes(inodeMap), nil
 }
 
 func detectFromProc(q Query) ([]Listener, error) {
@@ -47,13 +62,14 @@ func detectFromProc(q Query) ([]Listener, error) {
 			if !q.Contains(e.localPort) {
 				continue
 			}
-			if q.Interface != "" && e.localAddr != q.Interface && e.localAddr != "0.0.0.0" && e.localAddr != "::" {
+			if !matchesInterface(q, e.localAddr) {
 				continue
 			}
 			inodeMap[e.inode] = socketInfo{
 				port:     e.localPort,
 				protocol: proto,
 				iface:    e.localAddr,
+				uid:      e.uid,
 			}
 		}
 	}
@@ -69,10 +85,17 @@ func detectFromProc(q Query) ([]Listener, error) {
 	return listeners, nil
 }
 
+// matchesInterface reports whether a socket bound to addr satisfies the
+// query's interface filter. Wildcard binds match every interface.
+func matchesInterface(q Query, addr string) bool {
+	return q.Interface == "" || addr == q.Interface || addr == "0.0.0.0" || addr == "::"
+}
+
 type procNetEntry struct {
 	localAddr string
 	localPort int
 	state     int
+	uid       int
 	inode     uint64
 }
 
@@ -104,6 +127,11 @@ func parseProcNet(path string) ([]procNetEntry, error) {
 			continue
 		}
 
+		uid, err := strconv.Atoi(fields[7])
+		if err != nil {
+			continue
+		}
+
 		inode, err := strconv.ParseUint(fields[9], 10, 64)
 		if err != nil {
 			continue
@@ -113,6 +141,7 @@ func parseProcNet(path string) ([]procNetEntry, error) {
 			localAddr: localAddr,
 			localPort: localPort,
 			state:     int(state),
+			uid:       uid,
 			inode:     inode,
 		})
 	}
@@ -158,19 +187,24 @@ func parseHexAddr(s string) (addr string, port int, err error) {
 	return addr, port, nil
 }
 
+// findPIDsForInodes maps socket inodes to the processes holding them.
+// Sockets whose owner cannot be found — usually because another user's
+// /proc/<pid>/fd is unreadable — are returned with PID 0 so callers can
+// report them instead of silently dropping them.
 func findPIDsForInodes(inodeMap map[uint64]socketInfo) []Listener {
 	var listeners []Listener
 	seen := make(map[string]bool)
+	resolved := make(map[uint64]bool)
 
 	procDir, err := os.Open("/proc")
 	if err != nil {
-		return nil
+		return unresolvedListeners(inodeMap, resolved)
 	}
 	defer procDir.Close()
 
 	entries, err := procDir.Readdirnames(-1)
 	if err != nil {
-		return nil
+		return unresolvedListeners(inodeMap, resolved)
 	}
 
 	for _, entry := range entries {
@@ -205,6 +239,7 @@ func findPIDsForInodes(inodeMap map[uint64]socketInfo) []Listener {
 			if !ok {
 				continue
 			}
+			resolved[inode] = true
 
 			key := fmt.Sprintf("%d:%d:%s", pid, info.port, info.protocol)
 			if seen[key] {
@@ -217,9 +252,34 @@ func findPIDsForInodes(inodeMap map[uint64]socketInfo) []Listener {
 				Port:      info.port,
 				Protocol:  info.protocol,
 				Interface: info.iface,
+				UID:       info.uid,
 			})
 		}
 	}
 
+	return append(listeners, unresolvedListeners(inodeMap, resolved)...)
+}
+
+// unresolvedListeners returns owner-unknown listeners for every inode not in
+// resolved, sorted by port and protocol for stable output.
+func unresolvedListeners(inodeMap map[uint64]socketInfo, resolved map[uint64]bool) []Listener {
+	var listeners []Listener
+	for inode, info := range inodeMap {
+		if resolved[inode] {
+			continue
+		}
+		listeners = append(listeners, Listener{
+			Port:      info.port,
+			Protocol:  info.protocol,
+			Interface: info.iface,
+			UID:       info.uid,
+		})
+	}
+	sort.Slice(listeners, func(i, j int) bool {
+		if listeners[i].Port != listeners[j].Port {
+			return listeners[i].Port < listeners[j].Port
+		}
+		return listeners[i].Protocol < listeners[j].Protocol
+	})
 	return listeners
 }
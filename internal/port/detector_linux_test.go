@@ -2,7 +2,11 @@
 
 package port
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestParseHexAddr(t *testing.T) {
 	tests := []struct {
@@ -38,3 +42,51 @@ func TestParseHexAddr(t *testing.T) {
 		})
 	}
 }
+
+func TestParseProcNet(t *testing.T) {
+	content := "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n" +
+		"   0: 0100007F:0BB8 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 12345 1 0000000000000000 100 0 0 10 0\n" +
+		"   1: 00000000:0050 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 67890 1 0000000000000000 100 0 0 10 0\n"
+	path := filepath.Join(t.TempDir(), "tcp")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := parseProcNet(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	want := procNetEntry{localAddr: "127.0.0.1", localPort: 3000, state: 0x0A, uid: 1000, inode: 12345}
+	if entries[0] != want {
+		t.Errorf("entries[0] = %+v, want %+v", entries[0], want)
+	}
+	if entries[1].uid != 0 || entries[1].localPort != 80 {
+		t.Errorf("entries[1] = %+v", entries[1])
+	}
+}
+
+func TestUnresolvedListeners(t *testing.T) {
+	inodeMap := map[uint64]socketInfo{
+		1: {port: 80, protocol: "tcp6", iface: "::", uid: 0},
+		2: {port: 80, protocol: "tcp", iface: "0.0.0.0", uid: 0},
+		3: {port: 3000, protocol: "tcp", iface: "127.0.0.1", uid: 1000},
+	}
+	resolved := map[uint64]bool{3: true}
+
+	got := unresolvedListeners(inodeMap, resolved)
+	want := []Listener{
+		{Port: 80, Protocol: "tcp", Interface: "0.0.0.0"},
+		{Port: 80, Protocol: "tcp6", Interface: "::"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d listeners, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("listener %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
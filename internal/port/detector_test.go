@@ -0,0 +1,39 @@
+package port
+
+import "testing"
+
+func TestGroupByPID(t *testing.T) {
+	listeners := []Listener{
+		{PID: 10, Port: 3000, Protocol: "tcp", Interface: "0.0.0.0"},
+		{PID: 20, Port: 5432, Protocol: "tcp", Interface: "127.0.0.1"},
+		{PID: 10, Port: 3000, Protocol: "tcp6", Interface: "::"},
+		{PID: 10, Port: 3001, Protocol: "tcp", Interface: "0.0.0.0"},
+		{PID: 20, Port: 5432, Protocol: "tcp", Interface: "127.0.0.1"}, // duplicate
+	}
+
+	groups := GroupByPID(listeners)
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(groups))
+	}
+	if groups[0][0].PID != 10 || len(groups[0]) != 3 {
+		t.Errorf("first group = %+v, want 3 listeners for PID 10", groups[0])
+	}
+	if groups[1][0].PID != 20 || len(groups[1]) != 1 {
+		t.Errorf("second group = %+v, want 1 listener for PID 20", groups[1])
+	}
+	if groups[0][2].Port != 3001 {
+		t.Errorf("order not preserved: %+v", groups[0])
+	}
+}
+
+func TestGroupByPIDUnknownOwner(t *testing.T) {
+	listeners := []Listener{
+		{Port: 80, Protocol: "tcp", Interface: "0.0.0.0"},
+		{Port: 80, Protocol: "tcp6", Interface: "::"},
+	}
+
+	groups := GroupByPID(listeners)
+	if len(groups) != 2 {
+		t.Fatalf("expected unknown-owner listeners in separate groups, got %d", len(groups))
+	}
+}
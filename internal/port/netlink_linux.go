@@ -0,0 +1,201 @@
+//go:build linux
+
+package port
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// Constants from linux/sock_diag.h and linux/inet_diag.h that the syscall
+// package does not export.
+const (
+	sockDiagByFamily     = 20
+	inetDiagReqBytecode  = 1
+	inetDiagBCSGE        = 2
+	inetDiagBCSLE        = 3
+	inetDiagReqV2Len     = 56
+	inetDiagMsgLen       = 72
+	tcpListenState       = 10
+	allStates            = 0xffffffff
+	netlinkRecvBufferLen = 1 << 16
+)
+
+// diagTarget is one family/protocol combination to dump, named the same way
+// as the matching /proc/net file so listeners look identical either way.
+type diagTarget struct {
+	name     string
+	family   uint8
+	protocol uint8
+	states   uint32
+}
+
+var diagTargets = []diagTarget{
+	{"tcp", syscall.AF_INET, syscall.IPPROTO_TCP, 1 << tcpListenState},
+	{"tcp6", syscall.AF_INET6, syscall.IPPROTO_TCP, 1 << tcpListenState},
+	// /proc/net/udp lists every UDP socket regardless of state
+	{"udp", syscall.AF_INET, syscall.IPPROTO_UDP, allStates},
+	{"udp6", syscall.AF_INET6, syscall.IPPROTO_UDP, allStates},
+}
+
+// socketsFromNetlink asks the kernel for sockets matching the query through
+// NETLINK_SOCK_DIAG. The port range is filtered kernel-side with inet_diag
+// bytecode; the interface filter is applied here, like in detectFromProc.
+func socketsFromNetlink(q Query) (map[uint64]socketInfo, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, syscall.NETLINK_INET_DIAG)
+	if err != nil {
+		return nil, fmt.Errorf("netlink socket: %w", err)
+	}
+	defer syscall.Close(fd)
+
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("netlink bind: %w", err)
+	}
+
+	inodeMap := make(map[uint64]socketInfo)
+	for i, target := range diagTargets {
+		seq := uint32(i + 1)
+		req := buildDiagRequest(target, q, seq)
+		if err := syscall.Sendto(fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+			return nil, fmt.Errorf("netlink send: %w", err)
+		}
+		if err := receiveDiagDump(fd, seq, func(m diagMsg) {
+			if !matchesInterface(q, m.addr) {
+				return
+			}
+			inodeMap[m.inode] = socketInfo{
+				port:     m.port,
+				protocol: target.name,
+				iface:    m.addr,
+				uid:      m.uid,
+			}
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return inodeMap, nil
+}
+
+// buildDiagRequest encodes an inet_diag_req_v2 dump request for one target,
+// followed by a bytecode filter restricting the local port to the query range.
+func buildDiagRequest(target diagTarget, q Query, seq uint32) []byte {
+	bytecode := portRangeBytecode(q.StartPort, q.EndPort)
+	attrLen := syscall.SizeofRtAttr + len(bytecode)
+	total := syscall.NLMSG_HDRLEN + inetDiagReqV2Len + attrLen
+
+	b := make([]byte, total)
+	ne := binary.NativeEndian
+
+	// struct nlmsghdr
+	ne.PutUint32(b[0:4], uint32(total))
+	ne.PutUint16(b[4:6], sockDiagByFamily)
+	ne.PutUint16(b[6:8], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	ne.PutUint32(b[8:12], seq)
+
+	// struct inet_diag_req_v2; the socket id is left zero for a dump
+	r := b[syscall.NLMSG_HDRLEN:]
+	r[0] = target.family
+	r[1] = target.protocol
+	ne.PutUint32(r[4:8], target.states)
+
+	// struct rtattr carrying INET_DIAG_REQ_BYTECODE
+	a := r[inetDiagReqV2Len:]
+	ne.PutUint16(a[0:2], uint16(attrLen))
+	ne.PutUint16(a[2:4], inetDiagReqBytecode)
+	copy(a[syscall.SizeofRtAttr:], bytecode)
+
+	return b
+}
+
+// portRangeBytecode returns inet_diag bytecode accepting sockets whose source
+// port is within [start, end]. Each inet_diag_bc_op is {code, yes, no}; a
+// comparison op is followed by a second op whose "no" field holds the port.
+// Jumping past the end of the program (len+4) rejects the socket.
+func portRangeBytecode(start, end int) []byte {
+	b := make([]byte, 16)
+	ne := binary.NativeEndian
+	putOp := func(off int, code uint8, yes uint8, no uint16) {
+		b[off] = code
+		b[off+1] = yes
+		ne.PutUint16(b[off+2:off+4], no)
+	}
+	putOp(0, inetDiagBCSGE, 8, 20)
+	putOp(4, 0, 0, uint16(start))
+	putOp(8, inetDiagBCSLE, 8, 12)
+	putOp(12, 0, 0, uint16(end))
+	return b
+}
+
+// receiveDiagDump reads netlink messages until NLMSG_DONE, calling fn for
+// each socket in the dump.
+func receiveDiagDump(fd int, seq uint32, fn func(diagMsg)) error {
+	buf := make([]byte, netlinkRecvBufferLen)
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return fmt.Errorf("netlink receive: %w", err)
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return fmt.Errorf("netlink parse: %w", err)
+		}
+		for _, m := range msgs {
+			if m.Header.Seq != seq {
+				continue
+			}
+			switch m.Header.Type {
+			case syscall.NLMSG_DONE:
+				return nil
+			case syscall.NLMSG_ERROR:
+				if len(m.Data) >= 4 {
+					if errno := int32(binary.NativeEndian.Uint32(m.Data[0:4])); errno != 0 {
+						return fmt.Errorf("netlink: %w", syscall.Errno(-errno))
+					}
+				}
+				return nil
+			case sockDiagByFamily:
+				if d, ok := parseDiagMsg(m.Data); ok {
+					fn(d)
+				}
+			}
+		}
+	}
+}
+
+// diagMsg is the subset of struct inet_diag_msg zap cares about.
+type diagMsg struct {
+	addr  string
+	port  int
+	uid   int
+	inode uint64
+}
+
+func parseDiagMsg(data []byte) (diagMsg, bool) {
+	if len(data) < inetDiagMsgLen {
+		return diagMsg{}, false
+	}
+	family := data[0]
+	// struct inet_diag_sockid starts at offset 4; ports and addresses are
+	// in network byte order.
+	id := data[4:52]
+	port := int(binary.BigEndian.Uint16(id[0:2]))
+
+	var addr string
+	switch family {
+	case syscall.AF_INET:
+		addr = net.IP(id[4:8]).String()
+	case syscall.AF_INET6:
+		addr = net.IP(id[4:20]).String()
+	default:
+		return diagMsg{}, false
+	}
+
+	return diagMsg{
+		addr:  addr,
+		port:  port,
+		uid:   int(binary.NativeEndian.Uint32(data[64:68])),
+		inode: uint64(binary.NativeEndian.Uint32(data[68:72])),
+	}, true
+}
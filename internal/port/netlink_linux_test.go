@@ -0,0 +1,72 @@
+//go:build linux
+
+package port
+
+import (
+	"encoding/binary"
+	"syscall"
+	"testing"
+)
+
+func TestPortRangeBytecode(t *testing.T) {
+	b := portRangeBytecode(8080, 8090)
+	if len(b) != 16 {
+		t.Fatalf("len = %d, want 16", len(b))
+	}
+	ne := binary.NativeEndian
+	if b[0] != inetDiagBCSGE || b[8] != inetDiagBCSLE {
+		t.Errorf("op codes = %d, %d, want %d, %d", b[0], b[8], inetDiagBCSGE, inetDiagBCSLE)
+	}
+	if got := ne.Uint16(b[6:8]); got != 8080 {
+		t.Errorf("start port = %d, want 8080", got)
+	}
+	if got := ne.Uint16(b[14:16]); got != 8090 {
+		t.Errorf("end port = %d, want 8090", got)
+	}
+}
+
+func TestParseDiagMsg(t *testing.T) {
+	tests := []struct {
+		name     string
+		family   uint8
+		addr     []byte
+		wantAddr string
+	}{
+		{"ipv4 loopback", syscall.AF_INET, []byte{127, 0, 0, 1}, "127.0.0.1"},
+		{"ipv4 any", syscall.AF_INET, []byte{0, 0, 0, 0}, "0.0.0.0"},
+		{"ipv6 any", syscall.AF_INET6, make([]byte, 16), "::"},
+		{"ipv6 loopback", syscall.AF_INET6, append(make([]byte, 15), 1), "::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := make([]byte, inetDiagMsgLen)
+			data[0] = tt.family
+			binary.BigEndian.PutUint16(data[4:6], 3000)
+			copy(data[8:], tt.addr)
+			binary.NativeEndian.PutUint32(data[64:68], 1000)
+			binary.NativeEndian.PutUint32(data[68:72], 424242)
+
+			m, ok := parseDiagMsg(data)
+			if !ok {
+				t.Fatal("parseDiagMsg returned !ok")
+			}
+			if m.addr != tt.wantAddr {
+				t.Errorf("addr = %q, want %q", m.addr, tt.wantAddr)
+			}
+			if m.port != 3000 {
+				t.Errorf("port = %d, want 3000", m.port)
+			}
+			if m.uid != 1000 {
+				t.Errorf("uid = %d, want 1000", m.uid)
+			}
+			if m.inode != 424242 {
+				t.Errorf("inode = %d, want 424242", m.inode)
+			}
+		})
+	}
+
+	if _, ok := parseDiagMsg(make([]byte, 10)); ok {
+		t.Error("expected short message to be rejected")
+	}
+}
@@ -1,7 +1,11 @@
 package process
 
 import (
+	"fmt"
+
+	"github.com/dnlvgl/zap/internal/cgroup"
 	"github.com/dnlvgl/zap/internal/container"
+	"github.com/dnlvgl/zap/internal/port"
 	"github.com/dnlvgl/zap/internal/systemd"
 )
 
@@ -10,24 +14,208 @@ type Context struct {
 	Info        Info
 	Container   *container.Info
 	SystemdUnit string
+	// SystemdScope tells whether SystemdUnit belongs to the system manager
+	// or to a user's manager.
+	SystemdScope systemd.Scope
+	// SystemdRestart is the unit's Restart= setting, e.g. "always".
+	SystemdRestart string
+	// SocketUnit is set for the sockets a service manager holds on behalf
+	// of a .socket unit; Info is then the manager (e.g. PID 1).
+	SocketUnit *systemd.Socket
+	// ActivatedBy is the .socket unit that starts SystemdUnit on demand.
+	ActivatedBy *systemd.Socket
+	// Cgroup is the process's cgroup v2, set when it can be killed as a
+	// whole (see cgroup.Of).
+	Cgroup *cgroup.Group
+	// Frozen is set when the target is paused: stopped by SIGSTOP, its
+	// cgroup frozen, or its container paused.
+	Frozen bool
+	// OwnerUnknown is set when the listening socket could not be traced back
+	// to a process; Info then only carries the socket's UID and ports.
+	OwnerUnknown bool
 }
 
 // GatherContext collects full process context including container and systemd info.
 func GatherContext(pid, port int) (Context, error) {
+	return gatherContext(pid, port, container.NewSnapshot())
+}
+
+func gatherContext(pid, port int, containers *container.Snapshot) (Context, error) {
 	info, err := Gather(pid)
 	if err != nil {
 		return Context{}, err
 	}
 
 	ctx := Context{
-		Info:        info,
-		Container:   container.Detect(pid, port),
-		SystemdUnit: systemd.Detect(pid),
+		Info:      info,
+		Container: containers.Detect(pid, port),
+		Cgroup:    cgroup.Of(pid),
+	}
+	ctx.Frozen = info.Stopped || (ctx.Cgroup != nil && ctx.Cgroup.Frozen) ||
+		(ctx.Container != nil && ctx.Container.Paused)
+	ctx.SystemdUnit, ctx.SystemdScope = systemd.Detect(pid)
+	if ctx.SystemdUnit != "" {
+		ctx.SystemdRestart = systemd.RestartSetting(ctx.SystemdUnit, ctx.SystemdScope)
 	}
 
 	return ctx, nil
 }
 
+// GatherListenerContext collects context for the process owning a group of
+// listeners (see port.GroupByPID) and records every port it holds.
+// Container lookups go through containers, which callers share across all
+// groups of one refresh; nil means a fresh snapshot.
+func GatherListenerContext(listeners []port.Listener, containers *container.Snapshot) (Context, error) {
+	if len(listeners) == 0 {
+		return Context{}, fmt.Errorf("no listeners")
+	}
+	first := listeners[0]
+	var ctx Context
+	if first.OwnerKnown() {
+		var err error
+		if containers == nil {
+			containers = container.NewSnapshot()
+		}
+		ctx, err = gatherContext(first.PID, first.Port, containers)
+		if err != nil {
+			return Context{}, err
+		}
+	} else {
+		// Only the socket's UID is known; leave the PID at 0
+		ctx.Info = Info{UID: first.UID, User: lookupUser(first.UID)}
+		ctx.OwnerUnknown = true
+	}
+	ctx.addPorts(listeners)
+	return ctx, nil
+}
+
+func (c *Context) addPorts(listeners []port.Listener) {
+	for _, l := range listeners {
+		c.Info.Ports = append(c.Info.Ports, PortBinding{
+			Port:      l.Port,
+			Protocol:  l.Protocol,
+			Interface: l.Interface,
+		})
+	}
+}
+
+// GatherListeners collects one Context per process holding any of
+// listeners (see GatherListenerContext). Sockets a systemd manager holds
+// for .socket units get one Context per socket unit instead, and services
+// started by a socket unit get ActivatedBy. errs reports processes whose
+// context could not be gathered, typically because they just exited.
+func GatherListeners(listeners []port.Listener, containers *container.Snapshot) (contexts []Context, errs []error) {
+	if containers == nil {
+		containers = container.NewSnapshot()
+	}
+	sockets := make(map[systemd.Scope][]systemd.Socket)
+	socketsOf := func(scope systemd.Scope) []systemd.Socket {
+		list, ok := sockets[scope]
+		if !ok {
+			list = systemd.Sockets(scope)
+			sockets[scope] = list
+		}
+		return list
+	}
+
+	for _, group := range port.GroupByPID(listeners) {
+		rest := group
+		if scope, ok := managerScope(group[0]); ok {
+			var units []socketGroup
+			units, rest = splitSocketUnits(group, socketsOf(scope))
+			for _, u := range units {
+				ctx, err := gatherSocketContext(u)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				contexts = append(contexts, ctx)
+			}
+			if len(rest) == 0 {
+				continue
+			}
+		}
+
+		ctx, err := GatherListenerContext(rest, containers)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("PID %d: %w", rest[0].PID, err))
+			continue
+		}
+		if ctx.IsSystemdManaged() {
+			for _, sock := range socketsOf(ctx.SystemdScope) {
+				if sock.ActivatesUnit(ctx.SystemdUnit) {
+					ctx.ActivatedBy = &sock
+					break
+				}
+			}
+		}
+		contexts = append(contexts, ctx)
+	}
+	return contexts, errs
+}
+
+// managerScope reports whether l may be held by a service manager for a
+// socket unit: it belongs to one, or it is a root-owned socket whose owner
+// we could not see, which for socket units is PID 1.
+func managerScope(l port.Listener) (systemd.Scope, bool) {
+	if !l.OwnerKnown() {
+		return systemd.Scope{}, l.UID == 0
+	}
+	return systemd.ManagerScope(l.PID)
+}
+
+// socketGroup is the listeners a manager holds for one socket unit.
+type socketGroup struct {
+	socket    systemd.Socket
+	listeners []port.Listener
+}
+
+// splitSocketUnits sorts a manager's listeners by the socket unit that
+// declares them; rest holds those no unit claims.
+func splitSocketUnits(listeners []port.Listener, sockets []systemd.Socket) (units []socketGroup, rest []port.Listener) {
+	index := make(map[string]int)
+	for _, l := range listeners {
+		claimed := false
+		for _, sock := range sockets {
+			if !sock.Listens(l.Port, l.Protocol) {
+				continue
+			}
+			i, ok := index[sock.Unit]
+			if !ok {
+				i = len(units)
+				index[sock.Unit] = i
+				units = append(units, socketGroup{socket: sock})
+			}
+			units[i].listeners = append(units[i].listeners, l)
+			claimed = true
+			break
+		}
+		if !claimed {
+			rest = append(rest, l)
+		}
+	}
+	return units, rest
+}
+
+// gatherSocketContext describes a socket unit's row, with its manager as
+// the process.
+func gatherSocketContext(g socketGroup) (Context, error) {
+	pid := g.listeners[0].PID
+	if pid == 0 {
+		pid = 1
+	}
+	// The manager's tree is every service it runs; leave it out
+	info, err := gatherInfo(pid, listProcs())
+	if err != nil {
+		return Context{}, fmt.Errorf("%s: %w", g.socket.Unit, err)
+	}
+	// Stopping the socket unit leaves the manager's other children alone
+	info.Children = nil
+	ctx := Context{Info: info, SocketUnit: &g.socket}
+	ctx.addPorts(g.listeners)
+	return ctx, nil
+}
+
 // IsContainerized returns true if the process runs inside a container.
 func (c Context) IsContainerized() bool {
 	return c.Container != nil
@@ -37,3 +225,9 @@ func (c Context) IsContainerized() bool {
 func (c Context) IsSystemdManaged() bool {
 	return c.SystemdUnit != ""
 }
+
+// IsSocketUnit returns true if the row stands for a .socket unit rather
+// than the process holding its sockets.
+func (c Context) IsSocketUnit() bool {
+	return c.SocketUnit != nil
+}
@@ -1,8 +1,13 @@
 package process
 
 import (
+	"errors"
+	"fmt"
+	"net"
 	"os"
-	"syscall"
+	"os/user"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,6 +24,14 @@ type Info struct {
 	StartTime  time.Time
 	ParentPID  int
 	Children   []int
+	Stopped    bool   // stopped by SIGSTOP, e.g. paused with zap
+	PGID       int    // process group ID, signalled as a whole by Ctrl-C
+	SID        int    // session ID
+	TTY        string // controlling terminal, e.g. "pts/3"; empty for none
+	// Ancestors runs from the parent up to, but excluding, PID 1.
+	Ancestors []Node
+	// Descendants lists every process below this one, depth first.
+	Descendants []Node
 }
 
 // PortBinding describes a port a process is listening on.
@@ -28,6 +41,17 @@ type PortBinding struct {
 	Interface string
 }
 
+// String returns the binding as ":3000/tcp".
+func (b PortBinding) String() string {
+	return fmt.Sprintf(":%d/%s", b.Port, b.Protocol)
+}
+
+// Address returns the binding including its interface, e.g.
+// "127.0.0.1:3000/tcp" or "[::]:3000/tcp6".
+func (b PortBinding) Address() string {
+	return net.JoinHostPort(b.Interface, strconv.Itoa(b.Port)) + "/" + b.Protocol
+}
+
 // Uptime returns the duration since the process started.
 func (i Info) Uptime() time.Duration {
 	if i.StartTime.IsZero() {
@@ -41,11 +65,57 @@ func (i Info) IsPrivileged() bool {
 	return i.UID != os.Getuid() && os.Getuid() != 0
 }
 
-// Signal sends a signal to the process.
-func (i Info) Signal(sig syscall.Signal) error {
-	proc, err := os.FindProcess(i.PID)
-	if err != nil {
-		return err
+// lookupUser resolves a UID to a username, falling back to the numeric UID.
+func lookupUser(uid int) string {
+	id := strconv.Itoa(uid)
+	if u, err := user.LookupId(id); err == nil {
+		return u.Username
+	}
+	return id
+}
+
+// ErrReused reports that a PID no longer belongs to the process zap
+// listed under it.
+var ErrReused = errors.New("was reused by another process")
+
+// Verify checks that i.PID still belongs to the process i describes, by
+// its start time and executable. A listing can be seconds old by the time
+// it is acted on, and the PID may have been handed to a new process. A
+// process that has exited passes; signalling it fails on its own.
+func (i Info) Verify() error {
+	if i.PID <= 0 {
+		return nil
+	}
+	start := readStartTime(i.PID)
+	exe := readExecutable(i.PID)
+	if start.IsZero() {
+		return nil
+	}
+	sameStart := i.StartTime.IsZero() || start.Equal(i.StartTime)
+	sameExe := i.Executable == "" || exe == "" || sameExecutable(i.Executable, exe)
+	if sameStart && sameExe {
+		return nil
+	}
+	now := exe
+	if now == "" {
+		now = "another process"
+	}
+	return fmt.Errorf("PID %d %w (now %s, started %s); refusing to signal it",
+		i.PID, ErrReused, now, start.Format("15:04:05"))
+}
+
+// VerifyRunning is Verify for actions that reach past the process to its
+// cgroup, group or tree. Those fail once the process has exited too, since
+// its cgroup, process group and children may by then be left to others.
+func (i Info) VerifyRunning() error {
+	if i.PID > 0 && !IsRunning(i.PID) {
+		return fmt.Errorf("PID %d has exited; refusing to signal the processes around it", i.PID)
 	}
-	return proc.Signal(sig)
+	return i.Verify()
+}
+
+// sameExecutable compares executable paths, ignoring the " (deleted)"
+// Linux appends once the binary is replaced, e.g. by a package upgrade.
+func sameExecutable(a, b string) bool {
+	return strings.TrimSuffix(a, " (deleted)") == strings.TrimSuffix(b, " (deleted)")
 }
@@ -6,15 +6,16 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"os/user"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 )
 
-// Gather collects information about a process by PID.
-func Gather(pid int) (Info, error) {
+// gatherInfo collects information about a process by PID, without the
+// tree around it. table is the listing Gather also walks the tree from.
+func gatherInfo(pid int, table procTable) (Info, error) {
 	proc, err := os.FindProcess(pid)
 	if err != nil {
 		return Info{}, fmt.Errorf("process %d not found", pid)
@@ -25,37 +26,58 @@ func Gather(pid int) (Info, error) {
 
 	info := Info{PID: pid}
 
-	// ps -p <pid> -o ppid=,uid=,rss=,command=
-	cmd := exec.Command("ps", "-p", strconv.Itoa(pid), "-o", "ppid=,uid=,rss=,command=")
+	// ps -p <pid> -o ppid=,uid=,rss=,stat=,command=
+	cmd := exec.Command("ps", "-p", strconv.Itoa(pid), "-o", "ppid=,uid=,rss=,stat=,command=")
 	out, err := cmd.Output()
 	if err == nil {
 		line := strings.TrimSpace(string(out))
 		fields := strings.Fields(line)
-		if len(fields) >= 4 {
+		if len(fields) >= 5 {
 			if ppid, err := strconv.Atoi(fields[0]); err == nil {
 				info.ParentPID = ppid
 			}
 			if uid, err := strconv.Atoi(fields[1]); err == nil {
 				info.UID = uid
-				if u, err := user.LookupId(fields[1]); err == nil {
-					info.User = u.Username
-				} else {
-					info.User = fields[1]
-				}
+				info.User = lookupUser(uid)
 			}
 			if rss, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
 				info.MemoryKB = rss
 			}
-			info.Command = strings.Join(fields[3:], " ")
+			info.Stopped = strings.HasPrefix(fields[3], "T")
+			info.Command = strings.Join(fields[4:], " ")
 		}
 	}
 
-	info.StartTime = readStartTime(pid)
-	info.Children = findChildren(pid)
+	info.Children = table.children(pid)
+	if node, _, ok := table.node(pid); ok {
+		info.PGID, info.SID, info.TTY = node.PGID, node.SID, node.TTY
+		info.StartTime = node.StartTime
+	} else {
+		info.StartTime = readStartTime(pid)
+	}
 
 	return info, nil
 }
 
+// readExecutable returns "": ps has no reliable executable path, so
+// Verify goes by the start time alone.
+func readExecutable(pid int) string {
+	return ""
+}
+
+// Signal sends a signal to the process after checking that its PID was
+// not reused (see Verify).
+func (i Info) Signal(sig syscall.Signal) error {
+	if err := i.Verify(); err != nil {
+		return err
+	}
+	proc, err := os.FindProcess(i.PID)
+	if err != nil {
+		return err
+	}
+	return proc.Signal(sig)
+}
+
 func readStartTime(pid int) time.Time {
 	cmd := exec.Command("ps", "-p", strconv.Itoa(pid), "-o", "lstart=")
 	out, err := cmd.Output()
@@ -71,26 +93,99 @@ func readStartTime(pid int) time.Time {
 	return t
 }
 
-func findChildren(pid int) []int {
-	cmd := exec.Command("ps", "-ax", "-o", "pid=,ppid=")
-	out, err := cmd.Output()
+// psEntry is one process listed by listProcs.
+type psEntry struct {
+	ppid    int
+	pgid    int
+	tty     string
+	start   time.Time
+	command string
+}
+
+// procTable is a snapshot of every process, keyed by PID.
+type procTable map[int]psEntry
+
+// listProcs lists every process with a single ps call, so a Gather walks
+// up and down the tree from one listing.
+func listProcs() procTable {
+	out, err := exec.Command("ps", "-ax", "-o", "pid=,ppid=,pgid=,tty=,lstart=,command=").Output()
 	if err != nil {
 		return nil
 	}
-	var children []int
+	table := make(procTable)
 	for _, line := range strings.Split(string(out), "\n") {
+		// lstart takes five fields, e.g. "Thu Feb 27 10:30:00 2026"
 		fields := strings.Fields(line)
-		if len(fields) < 2 {
+		if len(fields) < 10 {
 			continue
 		}
-		childPID, err1 := strconv.Atoi(fields[0])
-		parentPID, err2 := strconv.Atoi(fields[1])
-		if err1 != nil || err2 != nil {
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		pgid, err3 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil || err3 != nil {
 			continue
 		}
-		if parentPID == pid {
-			children = append(children, childPID)
+		tty := fields[3]
+		if tty == "??" {
+			tty = ""
+		}
+		start, _ := time.ParseInLocation("Mon Jan _2 15:04:05 2006", strings.Join(fields[4:9], " "), time.Local)
+		table[pid] = psEntry{ppid: ppid, pgid: pgid, tty: tty, start: start, command: strings.Join(fields[9:], " ")}
+	}
+	return table
+}
+
+func (t procTable) children(pid int) []int {
+	var children []int
+	for child, e := range t {
+		if e.ppid == pid {
+			children = append(children, child)
 		}
 	}
+	sort.Ints(children)
 	return children
 }
+
+func (t procTable) node(pid int) (Node, int, bool) {
+	e, ok := t[pid]
+	if !ok {
+		return Node{}, 0, false
+	}
+	sid, _ := syscall.Getsid(pid)
+	return Node{PID: pid, Command: e.command, PGID: e.pgid, SID: sid, TTY: e.tty, StartTime: e.start}, e.ppid, true
+}
+
+// Descendants lists the processes below pid as they are now, depth first.
+func Descendants(pid int) []Node {
+	table := listProcs()
+	return descendants(pid, table.node, table.children)
+}
+
+// IsRunning returns true if the process exists and is not a zombie.
+func IsRunning(pid int) bool {
+	// EPERM means the process exists but belongs to another user
+	if err := syscall.Kill(pid, 0); err != nil && err != syscall.EPERM {
+		return false
+	}
+	out, err := exec.Command("ps", "-p", strconv.Itoa(pid), "-o", "stat=").Output()
+	if err != nil {
+		return false
+	}
+	return !strings.HasPrefix(strings.TrimSpace(string(out)), "Z")
+}
+
+// GroupMembers lists the processes in process group pgid, by PID.
+func GroupMembers(pgid int) []Node {
+	table := listProcs()
+	var members []Node
+	for pid, e := range table {
+		if e.pgid != pgid {
+			continue
+		}
+		if node, _, ok := table.node(pid); ok {
+			members = append(members, node)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].PID < members[j].PID })
+	return members
+}
@@ -3,17 +3,36 @@
 package process
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"os/user"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
+
+	"golang.org/x/sys/unix"
 )
 
-// Gather collects information about a process by PID.
-func Gather(pid int) (Info, error) {
+// procFS looks processes up in /proc as they are at each lookup.
+type procFS struct{}
+
+// listProcs returns the processes Gather walks; on Linux each one is read
+// from /proc when it is looked up.
+func listProcs() procFS {
+	return procFS{}
+}
+
+func (procFS) node(pid int) (Node, int, bool) { return readNode(pid) }
+
+func (procFS) children(pid int) []int { return findChildren(pid) }
+
+// gatherInfo collects information about a process by PID, without the
+// tree around it.
+func gatherInfo(pid int, _ procFS) (Info, error) {
 	procPath := filepath.Join("/proc", strconv.Itoa(pid))
 
 	if _, err := os.Stat(procPath); err != nil {
@@ -22,23 +41,9 @@ func Gather(pid int) (Info, error) {
 
 	info := Info{PID: pid}
 
-	// Read command line
-	if cmdline, err := os.ReadFile(filepath.Join(procPath, "cmdline")); err == nil {
-		// cmdline is null-separated
-		parts := strings.Split(string(cmdline), "\x00")
-		var nonEmpty []string
-		for _, p := range parts {
-			if p != "" {
-				nonEmpty = append(nonEmpty, p)
-			}
-		}
-		info.Command = strings.Join(nonEmpty, " ")
-	}
+	info.Command = readCmdline(pid)
 
-	// Read executable path
-	if exe, err := os.Readlink(filepath.Join(procPath, "exe")); err == nil {
-		info.Executable = exe
-	}
+	info.Executable = readExecutable(pid)
 
 	// Read status file for UID and PPID
 	if status, err := os.ReadFile(filepath.Join(procPath, "status")); err == nil {
@@ -51,11 +56,7 @@ func Gather(pid int) (Info, error) {
 				if len(fields) >= 2 {
 					uid, _ := strconv.Atoi(fields[1])
 					info.UID = uid
-					if u, err := user.LookupId(fields[1]); err == nil {
-						info.User = u.Username
-					} else {
-						info.User = fields[1]
-					}
+					info.User = lookupUser(uid)
 				}
 			}
 			if strings.HasPrefix(line, "VmRSS:") {
@@ -67,8 +68,12 @@ func Gather(pid int) (Info, error) {
 		}
 	}
 
-	// Read start time from /proc/PID/stat
+	// Read start time, state and session from /proc/PID/stat
 	info.StartTime = readStartTime(pid)
+	info.Stopped = readState(pid) == 'T'
+	if node, _, ok := readNode(pid); ok {
+		info.PGID, info.SID, info.TTY = node.PGID, node.SID, node.TTY
+	}
 
 	// Find child processes
 	info.Children = findChildren(pid)
@@ -76,17 +81,54 @@ func Gather(pid int) (Info, error) {
 	return info, nil
 }
 
+// readExecutable returns the path of a process's executable, or "" when
+// it can't be read, e.g. for another user's process.
+func readExecutable(pid int) string {
+	exe, _ := os.Readlink(filepath.Join("/proc", strconv.Itoa(pid), "exe"))
+	return exe
+}
+
+// Signal sends a signal to the process after checking that its PID was
+// not reused (see Verify). The signal goes through a pidfd opened before
+// the check, so it can't hit a process that took over the PID since.
+func (i Info) Signal(sig syscall.Signal) error {
+	fd, err := unix.PidfdOpen(i.PID, 0)
+	if errors.Is(err, unix.ESRCH) {
+		return os.ErrProcessDone
+	}
+	if err != nil {
+		// Kernels before 5.3, or pidfds blocked by a seccomp filter
+		if err := i.Verify(); err != nil {
+			return err
+		}
+		err = syscall.Kill(i.PID, sig)
+	} else {
+		defer unix.Close(fd)
+		if err := i.Verify(); err != nil {
+			return err
+		}
+		err = unix.PidfdSendSignal(fd, sig, nil, 0)
+	}
+	if errors.Is(err, unix.ESRCH) {
+		return os.ErrProcessDone
+	}
+	return err
+}
+
 func readStartTime(pid int) time.Time {
 	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
 	if err != nil {
 		return time.Time{}
 	}
+	return parseStartTime(string(stat))
+}
 
+// parseStartTime returns the start time in /proc/PID/stat, to the second.
+func parseStartTime(s string) time.Time {
 	// Fields in stat are space-separated, but comm (field 2) can contain spaces
 	// and is enclosed in parentheses. Find the last ')' to skip past it.
-	s := string(stat)
 	idx := strings.LastIndex(s, ")")
-	if idx < 0 {
+	if idx < 0 || idx+2 > len(s) {
 		return time.Time{}
 	}
 	fields := strings.Fields(s[idx+2:]) // skip ") "
@@ -100,17 +142,19 @@ func readStartTime(pid int) time.Time {
 		return time.Time{}
 	}
 
-	// Get system boot time
-	bootTime := getBootTime()
-	if bootTime.IsZero() {
+	boot := bootTime()
+	if boot.IsZero() {
 		return time.Time{}
 	}
 
 	clkTck := uint64(100) // sysconf(_SC_CLK_TCK), typically 100 on Linux
 	startSecs := startTicks / clkTck
-	return bootTime.Add(time.Duration(startSecs) * time.Second)
+	return boot.Add(time.Duration(startSecs) * time.Second)
 }
 
+// bootTime is read once; walking the process table needs it per process.
+var bootTime = sync.OnceValue(getBootTime)
+
 func getBootTime() time.Time {
 	data, err := os.ReadFile("/proc/stat")
 	if err != nil {
@@ -130,16 +174,142 @@ func getBootTime() time.Time {
 	return time.Time{}
 }
 
-func findChildren(pid int) []int {
-	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "task", strconv.Itoa(pid), "children"))
+// readCmdline returns the process's command line, joined by spaces.
+func readCmdline(pid int) string {
+	cmdline, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cmdline"))
 	if err != nil {
-		return nil
+		return ""
 	}
+	// cmdline is null-separated
+	parts := strings.Split(string(cmdline), "\x00")
+	var nonEmpty []string
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
+
+// findChildren lists the children of every thread of pid.
+func findChildren(pid int) []int {
+	tasks, _ := filepath.Glob(filepath.Join("/proc", strconv.Itoa(pid), "task", "*", "children"))
 	var children []int
-	for _, s := range strings.Fields(string(data)) {
-		if child, err := strconv.Atoi(s); err == nil {
-			children = append(children, child)
+	for _, task := range tasks {
+		data, err := os.ReadFile(task)
+		if err != nil {
+			continue
+		}
+		for _, s := range strings.Fields(string(data)) {
+			if child, err := strconv.Atoi(s); err == nil {
+				children = append(children, child)
+			}
 		}
 	}
 	return children
 }
+
+// Descendants lists the processes below pid as they are now, depth first.
+func Descendants(pid int) []Node {
+	return descendants(pid, readNode, findChildren)
+}
+
+// GroupMembers lists the processes in process group pgid, by PID.
+func GroupMembers(pgid int) []Node {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	var members []Node
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		if node, _, ok := readNode(pid); ok && node.PGID == pgid {
+			members = append(members, node)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].PID < members[j].PID })
+	return members
+}
+
+// readNode reads a process's tree node and parent PID from
+// /proc/PID/stat.
+func readNode(pid int) (Node, int, bool) {
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return Node{}, 0, false
+	}
+	node, ppid, ok := parseStatNode(string(stat))
+	if !ok {
+		return Node{}, 0, false
+	}
+	node.PID = pid
+	node.StartTime = parseStartTime(string(stat))
+	if cmd := readCmdline(pid); cmd != "" {
+		node.Command = cmd
+	}
+	return node, ppid, true
+}
+
+// parseStatNode parses "pid (comm) state ppid pgrp session tty_nr ...".
+// Command is set to comm; kernel threads have no command line.
+func parseStatNode(stat string) (Node, int, bool) {
+	open, end := strings.Index(stat, "("), strings.LastIndex(stat, ")")
+	if open < 0 || end < open {
+		return Node{}, 0, false
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 5 {
+		return Node{}, 0, false
+	}
+	ppid, err1 := strconv.Atoi(fields[1])
+	pgid, err2 := strconv.Atoi(fields[2])
+	sid, err3 := strconv.Atoi(fields[3])
+	tty, err4 := strconv.Atoi(fields[4])
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return Node{}, 0, false
+	}
+	return Node{Command: stat[open+1 : end], PGID: pgid, SID: sid, TTY: ttyName(tty)}, ppid, true
+}
+
+// ttyName turns a tty_nr device number into a name like "pts/3".
+func ttyName(nr int) string {
+	if nr == 0 {
+		return ""
+	}
+	major := (nr >> 8) & 0xfff
+	minor := (nr & 0xff) | ((nr >> 12) & 0xfff00)
+	switch {
+	case major >= 136 && major <= 143:
+		return fmt.Sprintf("pts/%d", minor+(major-136)*256)
+	case major == 4 && minor < 64:
+		return fmt.Sprintf("tty%d", minor)
+	case major == 4:
+		return fmt.Sprintf("ttyS%d", minor-64)
+	}
+	return fmt.Sprintf("%d:%d", major, minor)
+}
+
+// readState returns the process state letter from /proc/PID/stat, e.g.
+// 'R', 'S' or 'T', or 0 when the process doesn't exist.
+func readState(pid int) byte {
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0
+	}
+	// State is the first field after the parenthesised comm
+	s := string(stat)
+	idx := strings.LastIndex(s, ")")
+	if idx < 0 || idx+2 >= len(s) {
+		return 0
+	}
+	return s[idx+2]
+}
+
+// IsRunning returns true if the process exists and is not a zombie.
+func IsRunning(pid int) bool {
+	state := readState(pid)
+	return state != 0 && state != 'Z' && state != 'X'
+}
@@ -0,0 +1,161 @@
+//go:build linux
+
+package process
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseStatNode(t *testing.T) {
+	tests := []struct {
+		name     string
+		stat     string
+		wantNode Node
+		wantPPID int
+		wantOK   bool
+	}{
+		{
+			name:     "terminal process",
+			stat:     "4133 (node) S 4120 4120 4100 34817 4120 4194304 1234 0 0 0",
+			wantNode: Node{Command: "node", PGID: 4120, SID: 4100, TTY: "pts/1"},
+			wantPPID: 4120,
+			wantOK:   true,
+		},
+		{
+			name:     "comm with spaces and parens",
+			stat:     "77 (tmux: server (1)) S 1 77 77 0 -1 4194560",
+			wantNode: Node{Command: "tmux: server (1)", PGID: 77, SID: 77},
+			wantPPID: 1,
+			wantOK:   true,
+		},
+		{name: "truncated", stat: "77 (x) S 1", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, ppid, ok := parseStatNode(tt.stat)
+			if node != tt.wantNode || ppid != tt.wantPPID || ok != tt.wantOK {
+				t.Errorf("parseStatNode() = %+v, %d, %v, want %+v, %d, %v",
+					node, ppid, ok, tt.wantNode, tt.wantPPID, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTTYName(t *testing.T) {
+	for nr, want := range map[int]string{
+		0:           "",
+		136<<8 | 3:  "pts/3",
+		4<<8 | 2:    "tty2",
+		4<<8 | 65:   "ttyS1",
+		137<<8 | 4:  "pts/260",
+		5<<8 | 1:    "5:1",
+		136<<8 | 0:  "pts/0",
+		34816 + 300: "pts/300",
+	} {
+		if got := ttyName(nr); got != want {
+			t.Errorf("ttyName(%d) = %q, want %q", nr, got, want)
+		}
+	}
+}
+
+func TestGatherTree(t *testing.T) {
+	info, err := Gather(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Ancestors) == 0 && os.Getppid() > 1 {
+		t.Fatal("expected the test process to have ancestors")
+	}
+	if len(info.Ancestors) > 0 && info.Ancestors[0].PID != os.Getppid() {
+		t.Errorf("Ancestors[0] = %d, want parent %d", info.Ancestors[0].PID, os.Getppid())
+	}
+}
+
+func TestGroupMembers(t *testing.T) {
+	info, err := Gather(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.PGID != syscall.Getpgrp() {
+		t.Errorf("PGID = %d, want %d", info.PGID, syscall.Getpgrp())
+	}
+	found := false
+	for _, m := range GroupMembers(info.PGID) {
+		if m.PGID != info.PGID {
+			t.Errorf("member %d has PGID %d, want %d", m.PID, m.PGID, info.PGID)
+		}
+		found = found || m.PID == os.Getpid()
+	}
+	if !found {
+		t.Errorf("GroupMembers(%d) does not list the test process", info.PGID)
+	}
+}
+
+func TestVerifyAndSignal(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	t.Cleanup(func() { _ = cmd.Process.Kill(); _ = cmd.Wait() })
+
+	info, err := Gather(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := info.Verify(); err != nil {
+		t.Fatalf("Verify() = %v, want nil", err)
+	}
+	if err := info.VerifyRunning(); err != nil {
+		t.Fatalf("VerifyRunning() = %v, want nil", err)
+	}
+	node, _, ok := readNode(info.PID)
+	if !ok || !node.StartTime.Equal(info.StartTime) {
+		t.Errorf("readNode() start time = %v, want %v", node.StartTime, info.StartTime)
+	}
+
+	upgraded := info
+	upgraded.Executable += " (deleted)"
+	if err := upgraded.Verify(); err != nil {
+		t.Errorf("Verify() with a replaced binary = %v, want nil", err)
+	}
+
+	older := info
+	older.StartTime = older.StartTime.Add(-time.Hour)
+	otherExe := info
+	otherExe.Executable = "/usr/bin/something-else"
+	for name, stale := range map[string]Info{"start time": older, "executable": otherExe} {
+		if err := stale.Verify(); !errors.Is(err, ErrReused) {
+			t.Errorf("Verify() with another %s = %v, want ErrReused", name, err)
+		}
+		if err := stale.Signal(syscall.SIGKILL); !errors.Is(err, ErrReused) {
+			t.Errorf("Signal() with another %s = %v, want ErrReused", name, err)
+		}
+	}
+	staleNode := Node{PID: info.PID, StartTime: older.StartTime}
+	if err := staleNode.Signal(syscall.SIGKILL); !errors.Is(err, ErrReused) {
+		t.Errorf("Node.Signal() with another start time = %v, want ErrReused", err)
+	}
+	if !IsRunning(info.PID) {
+		t.Fatal("refused signal killed the process")
+	}
+
+	if err := info.Signal(syscall.SIGKILL); err != nil {
+		t.Fatalf("Signal() = %v", err)
+	}
+	_ = cmd.Wait()
+	if err := info.Verify(); err != nil {
+		t.Errorf("Verify() after exit = %v, want nil", err)
+	}
+	if err := info.VerifyRunning(); err == nil {
+		t.Error("VerifyRunning() after exit = nil, want an error")
+	}
+	if err := info.Signal(syscall.SIGTERM); err == nil {
+		t.Error("Signal() after exit = nil, want an error")
+	}
+}
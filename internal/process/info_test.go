@@ -0,0 +1,26 @@
+package process
+
+import "testing"
+
+func TestPortBindingString(t *testing.T) {
+	tests := []struct {
+		binding     PortBinding
+		wantString  string
+		wantAddress string
+	}{
+		{PortBinding{Port: 3000, Protocol: "tcp", Interface: "127.0.0.1"}, ":3000/tcp", "127.0.0.1:3000/tcp"},
+		{PortBinding{Port: 3000, Protocol: "tcp6", Interface: "::"}, ":3000/tcp6", "[::]:3000/tcp6"},
+		{PortBinding{Port: 53, Protocol: "udp"}, ":53/udp", ":53/udp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantAddress, func(t *testing.T) {
+			if got := tt.binding.String(); got != tt.wantString {
+				t.Errorf("String() = %q, want %q", got, tt.wantString)
+			}
+			if got := tt.binding.Address(); got != tt.wantAddress {
+				t.Errorf("Address() = %q, want %q", got, tt.wantAddress)
+			}
+		})
+	}
+}
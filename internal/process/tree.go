@@ -0,0 +1,100 @@
+package process
+
+import (
+	"syscall"
+	"time"
+)
+
+// Node is one process of the tree around a listener.
+type Node struct {
+	PID     int
+	Command string
+	PGID    int    // process group ID
+	SID     int    // session ID
+	TTY     string // controlling terminal, e.g. "pts/3"; empty for none
+	Depth   int    // in Info.Descendants: 1 for a direct child
+
+	StartTime time.Time // zero when unknown
+}
+
+// Signal sends sig to the process n was listed as, refusing when its PID
+// has been reused since; see Info.Signal.
+func (n Node) Signal(sig syscall.Signal) error {
+	return Info{PID: n.PID, StartTime: n.StartTime}.Signal(sig)
+}
+
+// maxTreeDepth bounds the tree walks, which could otherwise loop when a
+// PID is reused while walking.
+const maxTreeDepth = 64
+
+// Gather collects information about a process by PID, including the
+// processes above and below it.
+func Gather(pid int) (Info, error) {
+	procs := listProcs()
+	info, err := gatherInfo(pid, procs)
+	if err != nil {
+		return Info{}, err
+	}
+	info.Ancestors = ancestors(info.ParentPID, procs.node)
+	info.Descendants = descendants(pid, procs.node, procs.children)
+	return info, nil
+}
+
+// Launcher returns the topmost ancestor in the same job, the process
+// group a shell started from the terminal: for a vite server started by
+// `npm run dev`, that is npm. The walk stops at the job's boundary, so a
+// shell nested in another shell (su, nix-shell, poetry shell) is never
+// the launcher. ok is false when the process wasn't started from a
+// terminal or its parent is the shell itself.
+func (i Info) Launcher() (launcher Node, ok bool) {
+	if i.TTY == "" || i.PGID == 0 {
+		return Node{}, false
+	}
+	for _, a := range i.Ancestors {
+		// The session leader is the shell, or whatever owns the terminal
+		if a.PGID != i.PGID || a.SID != i.SID || a.TTY != i.TTY || a.PID == a.SID {
+			break
+		}
+		launcher, ok = a, true
+	}
+	return launcher, ok
+}
+
+// nodeLookup returns a process's node and its parent's PID.
+type nodeLookup func(pid int) (node Node, ppid int, ok bool)
+
+// ancestors walks from ppid up to, but excluding, PID 1.
+func ancestors(ppid int, lookup nodeLookup) []Node {
+	var list []Node
+	for pid := ppid; pid > 1 && len(list) < maxTreeDepth; {
+		node, parent, ok := lookup(pid)
+		if !ok {
+			break
+		}
+		list = append(list, node)
+		pid = parent
+	}
+	return list
+}
+
+// descendants lists the processes below pid, depth first.
+func descendants(pid int, lookup nodeLookup, children func(int) []int) []Node {
+	var list []Node
+	var walk func(pid, depth int)
+	walk = func(pid, depth int) {
+		if depth > maxTreeDepth {
+			return
+		}
+		for _, child := range children(pid) {
+			node, _, ok := lookup(child)
+			if !ok {
+				continue
+			}
+			node.Depth = depth
+			list = append(list, node)
+			walk(child, depth+1)
+		}
+	}
+	walk(pid, 1)
+	return list
+}
@@ -0,0 +1,119 @@
+package process
+
+import (
+	"reflect"
+	"testing"
+)
+
+// fakeTree is a process table for the tree walks: pid -> (ppid, command).
+type fakeTree map[int]struct {
+	ppid int
+	cmd  string
+}
+
+func (f fakeTree) lookup(pid int) (Node, int, bool) {
+	p, ok := f[pid]
+	if !ok {
+		return Node{}, 0, false
+	}
+	return Node{PID: pid, Command: p.cmd}, p.ppid, true
+}
+
+func (f fakeTree) children(pid int) []int {
+	var children []int
+	for child := 2; child < 100; child++ {
+		if p, ok := f[child]; ok && p.ppid == pid {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+func TestTreeWalks(t *testing.T) {
+	tree := fakeTree{
+		2:  {1, "zsh"},
+		10: {2, "npm run dev"},
+		11: {10, "vite"},
+		12: {11, "esbuild"},
+		13: {11, "postcss"},
+		14: {13, "worker"},
+	}
+
+	got := ancestors(11, tree.lookup)
+	want := []Node{{PID: 11, Command: "vite"}, {PID: 10, Command: "npm run dev"}, {PID: 2, Command: "zsh"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ancestors = %+v, want %+v", got, want)
+	}
+
+	got = descendants(11, tree.lookup, tree.children)
+	want = []Node{
+		{PID: 12, Command: "esbuild", Depth: 1},
+		{PID: 13, Command: "postcss", Depth: 1},
+		{PID: 14, Command: "worker", Depth: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("descendants = %+v, want %+v", got, want)
+	}
+
+	// A PID reused as its own ancestor must not loop forever
+	loop := fakeTree{5: {6, "a"}, 6: {5, "b"}}
+	if got := ancestors(5, loop.lookup); len(got) != maxTreeDepth {
+		t.Errorf("ancestors of a loop = %d nodes, want %d", len(got), maxTreeDepth)
+	}
+}
+
+func TestLauncher(t *testing.T) {
+	shell := Node{PID: 100, Command: "zsh", PGID: 100, SID: 100, TTY: "pts/1"}
+	npm := Node{PID: 110, Command: "npm run dev", PGID: 110, SID: 100, TTY: "pts/1"}
+	sh := Node{PID: 111, Command: "sh -c vite", PGID: 110, SID: 100, TTY: "pts/1"}
+	terminal := Node{PID: 50, Command: "gnome-terminal-server", PGID: 50}
+	// A shell inside the login shell, e.g. from nix-shell or su
+	nested := Node{PID: 105, Command: "bash", PGID: 105, SID: 100, TTY: "pts/1"}
+	nestedNpm := Node{PID: 120, Command: "npm run dev", PGID: 120, SID: 100, TTY: "pts/1"}
+
+	tests := []struct {
+		name string
+		info Info
+		want int // launcher PID, 0 for none
+	}{
+		{
+			name: "npm run dev",
+			info: Info{PID: 112, PGID: 110, SID: 100, TTY: "pts/1", Ancestors: []Node{sh, npm, shell, terminal}},
+			want: 110,
+		},
+		{
+			name: "started by the shell",
+			info: Info{PID: 112, PGID: 112, SID: 100, TTY: "pts/1", Ancestors: []Node{shell, terminal}},
+		},
+		{
+			name: "npm in a nested shell",
+			info: Info{PID: 122, PGID: 120, SID: 100, TTY: "pts/1", Ancestors: []Node{nestedNpm, nested, shell, terminal}},
+			want: 120,
+		},
+		{
+			name: "started by a nested shell",
+			info: Info{PID: 122, PGID: 122, SID: 100, TTY: "pts/1", Ancestors: []Node{nested, shell, terminal}},
+		},
+		{
+			name: "job in its own group below npm",
+			info: Info{PID: 112, PGID: 112, SID: 100, TTY: "pts/1", Ancestors: []Node{npm, shell}},
+		},
+		{
+			name: "no terminal",
+			info: Info{PID: 112, SID: 112, Ancestors: []Node{{PID: 1000, Command: "containerd-shim"}}},
+		},
+		{
+			name: "daemonized with setsid",
+			info: Info{PID: 112, PGID: 112, SID: 112, TTY: "pts/1", Ancestors: []Node{npm, shell}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.info.Launcher()
+			if ok != (tt.want != 0) || got.PID != tt.want {
+				t.Errorf("Launcher() = %d, %v, want %d", got.PID, ok, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	systemdService   = "org.freedesktop.systemd1"
+	systemdPath      = "/org/freedesktop/systemd1"
+	managerInterface = "org.freedesktop.systemd1.Manager"
+	unitInterface    = "org.freedesktop.systemd1.Unit"
+	serviceInterface = "org.freedesktop.systemd1.Service"
+)
+
+// errBusUnreachable is returned when a bus cannot be dialed, so callers
+// can fall back to systemctl.
+var errBusUnreachable = errors.New("bus unreachable")
+
+const defaultBusAddress = "unix:path=/run/dbus/system_bus_socket"
+
+// shared holds the bus connections reused by detection, keyed by address,
+// so a refresh authenticates once rather than once per process.
+var shared struct {
+	mu    sync.Mutex
+	conns map[string]*dbus.Conn
+}
+
+// dialBus connects and authenticates to the bus at addr. A bus that
+// refuses us counts as unreachable too, since systemctl may still get through.
+func dialBus(ctx context.Context, addr string) (*dbus.Conn, error) {
+	conn, err := dbus.Dial(addr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errBusUnreachable, err)
+	}
+	// Auth has no context of its own; closing the connection ends it
+	done := make(chan error, 1)
+	go func() { done <- conn.Auth(nil) }()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		conn.Close()
+		err = <-done
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("%w: %v", errBusUnreachable, err)
+	}
+	var name string
+	if err := conn.BusObject().CallWithContext(ctx, "org.freedesktop.DBus.Hello", 0).Store(&name); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// withBus runs fn on the shared connection to the scope's bus, dialing it
+// on first use. A connection that fails with anything but an error reply
+// is dropped, and fn retried once on a fresh one in case the bus was
+// restarted.
+func withBus(ctx context.Context, scope Scope, fn func(*dbus.Conn) error) error {
+	addr := scope.busAddress()
+	shared.mu.Lock()
+	defer shared.mu.Unlock()
+	if shared.conns == nil {
+		shared.conns = make(map[string]*dbus.Conn)
+	}
+	for attempt := 0; ; attempt++ {
+		c, reused := shared.conns[addr]
+		if !reused {
+			var err error
+			if c, err = dialBus(ctx, addr); err != nil {
+				return err
+			}
+			shared.conns[addr] = c
+		}
+		err := fn(c)
+		if err == nil || isReplyError(err) {
+			return err
+		}
+		c.Close()
+		delete(shared.conns, addr)
+		if !reused || attempt > 0 {
+			return err
+		}
+	}
+}
+
+// isReplyError reports whether err is an error reply from a peer, e.g.
+// org.freedesktop.systemd1.NoSuchUnit, rather than a broken connection.
+func isReplyError(err error) bool {
+	var replyErr dbus.Error
+	return errors.As(err, &replyErr)
+}
+
+// getProperty reads one property of a systemd object into v.
+func getProperty(ctx context.Context, c *dbus.Conn, path dbus.ObjectPath, iface, name string, v any) error {
+	var value dbus.Variant
+	err := c.Object(systemdService, path).
+		CallWithContext(ctx, "org.freedesktop.DBus.Properties.Get", 0, iface, name).Store(&value)
+	if err != nil {
+		return err
+	}
+	return value.Store(v)
+}
+
+// managerCall calls a Manager method that returns an object path.
+func managerCall(ctx context.Context, c *dbus.Conn, method string, args ...any) (dbus.ObjectPath, error) {
+	var path dbus.ObjectPath
+	err := c.Object(systemdService, systemdPath).
+		CallWithContext(ctx, managerInterface+"."+method, 0, args...).Store(&path)
+	return path, err
+}
+
+// busDetect asks the system manager which unit pid belongs to and returns
+// it if pid is the main process of a service.
+func busDetect(ctx context.Context, pid int) (string, error) {
+	var unit string
+	err := withBus(ctx, Scope{}, func(c *dbus.Conn) error {
+		path, err := managerCall(ctx, c, "GetUnitByPID", uint32(pid))
+		if err != nil {
+			return err
+		}
+		var name string
+		if err := getProperty(ctx, c, path, unitInterface, "Id", &name); err != nil {
+			return err
+		}
+		if !isServiceUnit(name) || isInfrastructureUnit(name) {
+			return nil
+		}
+		var mainPID uint32
+		if err := getProperty(ctx, c, path, serviceInterface, "MainPID", &mainPID); err != nil {
+			return err
+		}
+		if mainPID == uint32(pid) {
+			unit = name
+		}
+		return nil
+	})
+	if isReplyError(err) {
+		// e.g. NoUnitForPID
+		return "", nil
+	}
+	return unit, err
+}
+
+// busMainPID reads a service's MainPID property.
+func busMainPID(ctx context.Context, unit string, scope Scope) (uint32, error) {
+	var mainPID uint32
+	err := withBus(ctx, scope, func(c *dbus.Conn) error {
+		path, err := managerCall(ctx, c, "GetUnit", unit)
+		if err != nil {
+			return err
+		}
+		return getProperty(ctx, c, path, serviceInterface, "MainPID", &mainPID)
+	})
+	return mainPID, err
+}
+
+// busRestartSetting reads a service's Restart= property.
+func busRestartSetting(ctx context.Context, unit string, scope Scope) (string, error) {
+	var restart string
+	err := withBus(ctx, scope, func(c *dbus.Conn) error {
+		path, err := managerCall(ctx, c, "GetUnit", unit)
+		if err != nil {
+			return err
+		}
+		return getProperty(ctx, c, path, serviceInterface, "Restart", &restart)
+	})
+	return restart, err
+}
+
+// busStop stops unit and waits for its job to finish. It uses its own
+// connection because it subscribes to systemd's job signals.
+func busStop(ctx context.Context, unit string, scope Scope) error {
+	c, err := dialBus(ctx, scope.busAddress())
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	signals := make(chan *dbus.Signal, 16)
+	c.Signal(signals)
+	if err := c.AddMatchSignalContext(ctx, dbus.WithMatchSender(systemdService),
+		dbus.WithMatchInterface(managerInterface), dbus.WithMatchMember("JobRemoved")); err != nil {
+		return err
+	}
+	if err := c.Object(systemdService, systemdPath).CallWithContext(ctx, managerInterface+".Subscribe", 0).Err; err != nil {
+		return err
+	}
+	job, err := managerCall(ctx, c, "StopUnit", unit, "replace")
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for job %s: %w", job, ctx.Err())
+		case sig, ok := <-signals:
+			if !ok {
+				return fmt.Errorf("waiting for job %s: connection closed", job)
+			}
+			if result, ok := jobResult(sig, job); ok {
+				if result != "done" {
+					return fmt.Errorf("stopping %s: job %s", unit, result)
+				}
+				return nil
+			}
+		}
+	}
+}
+
+// jobResult extracts the result of job from a JobRemoved(u id, o job,
+// s unit, s result) signal.
+func jobResult(sig *dbus.Signal, job dbus.ObjectPath) (string, bool) {
+	if sig.Name != managerInterface+".JobRemoved" || len(sig.Body) != 4 {
+		return "", false
+	}
+	if path, _ := sig.Body[1].(dbus.ObjectPath); path != job {
+		return "", false
+	}
+	result, _ := sig.Body[3].(string)
+	return result, true
+}
+
+// needsPrivileges reports whether a bus error means we lack permission.
+func needsPrivileges(err error) bool {
+	var replyErr dbus.Error
+	if !errors.As(err, &replyErr) {
+		return false
+	}
+	switch replyErr.Name {
+	case "org.freedesktop.DBus.Error.AccessDenied",
+		"org.freedesktop.DBus.Error.InteractiveAuthorizationRequired":
+		return true
+	}
+	return false
+}
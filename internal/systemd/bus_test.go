@@ -0,0 +1,381 @@
+package systemd
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// fakeBus serves a minimal message bus on a unix socket and points
+// DBUS_SYSTEM_BUS_ADDRESS and DBUS_SESSION_BUS_ADDRESS at it. handle returns the messages to send in
+// response to each method call; Hello, AddMatch and Subscribe are answered
+// with an empty reply unless handle answers them.
+func fakeBus(t *testing.T, handle func(call *dbus.Message) []*dbus.Message) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bus.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	t.Setenv("DBUS_SYSTEM_BUS_ADDRESS", "unix:path="+path)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "unix:path="+path)
+	t.Cleanup(closeSharedConns)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveBus(conn, handle)
+		}
+	}()
+}
+
+func serveBus(conn net.Conn, handle func(call *dbus.Message) []*dbus.Message) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	// The client probes with a bare AUTH, then picks EXTERNAL
+	if line, err := r.ReadString('\n'); err != nil || line != "\x00AUTH\r\n" {
+		return
+	}
+	conn.Write([]byte("REJECTED EXTERNAL\r\n"))
+	if line, err := r.ReadString('\n'); err != nil || !strings.HasPrefix(line, "AUTH EXTERNAL") {
+		return
+	}
+	conn.Write([]byte("OK 0123456789abcdef0123456789abcdef\r\n"))
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		if line == "BEGIN\r\n" {
+			break
+		}
+		// NEGOTIATE_UNIX_FD
+		conn.Write([]byte("ERROR\r\n"))
+	}
+	for {
+		call, err := dbus.DecodeMessage(r)
+		if err != nil {
+			return
+		}
+		out := handle(call)
+		if out == nil {
+			out = []*dbus.Message{reply(call)}
+			if member(call) == "Hello" {
+				out = []*dbus.Message{reply(call, ":1.1")}
+			}
+		}
+		for _, msg := range out {
+			if err := msg.EncodeTo(conn, binary.LittleEndian); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func closeSharedConns() {
+	for addr, c := range shared.conns {
+		c.Close()
+		delete(shared.conns, addr)
+	}
+}
+
+func member(call *dbus.Message) string {
+	name, _ := call.Headers[dbus.FieldMember].Value().(string)
+	return name
+}
+
+func objectPathOf(call *dbus.Message) dbus.ObjectPath {
+	path, _ := call.Headers[dbus.FieldPath].Value().(dbus.ObjectPath)
+	return path
+}
+
+func message(typ dbus.Type, headers map[dbus.HeaderField]any, body ...any) *dbus.Message {
+	msg := &dbus.Message{Type: typ, Headers: make(map[dbus.HeaderField]dbus.Variant), Body: body}
+	for field, v := range headers {
+		msg.Headers[field] = dbus.MakeVariant(v)
+	}
+	if len(body) > 0 {
+		msg.Headers[dbus.FieldSignature] = dbus.MakeVariant(dbus.SignatureOf(body...))
+	}
+	return msg
+}
+
+func reply(call *dbus.Message, body ...any) *dbus.Message {
+	return message(dbus.TypeMethodReply, map[dbus.HeaderField]any{dbus.FieldReplySerial: call.Serial()}, body...)
+}
+
+func errorReply(call *dbus.Message, name, text string) *dbus.Message {
+	return message(dbus.TypeError, map[dbus.HeaderField]any{
+		dbus.FieldReplySerial: call.Serial(),
+		dbus.FieldErrorName:   name,
+	}, text)
+}
+
+func jobRemoved(job dbus.ObjectPath, unit, result string) *dbus.Message {
+	return message(dbus.TypeSignal, map[dbus.HeaderField]any{
+		dbus.FieldPath:      dbus.ObjectPath(systemdPath),
+		dbus.FieldInterface: managerInterface,
+		dbus.FieldMember:    "JobRemoved",
+	}, uint32(1), job, unit, result)
+}
+
+func TestBusDetect(t *testing.T) {
+	units := map[uint32]struct {
+		path    dbus.ObjectPath
+		id      string
+		mainPID uint32
+	}{
+		100: {"/org/freedesktop/systemd1/unit/nginx_2eservice", "nginx.service", 100},
+		101: {"/org/freedesktop/systemd1/unit/nginx_2eservice", "nginx.service", 100},
+		200: {"/org/freedesktop/systemd1/unit/session_2d1_2escope", "session-1.scope", 0},
+		300: {"/org/freedesktop/systemd1/unit/docker_2eservice", "docker.service", 300},
+	}
+	fakeBus(t, func(call *dbus.Message) []*dbus.Message {
+		switch member(call) {
+		case "GetUnitByPID":
+			u, ok := units[call.Body[0].(uint32)]
+			if !ok {
+				return []*dbus.Message{errorReply(call, "org.freedesktop.systemd1.NoUnitForPID", "No unit for PID")}
+			}
+			return []*dbus.Message{reply(call, u.path)}
+		case "Get":
+			for _, u := range units {
+				if u.path != objectPathOf(call) {
+					continue
+				}
+				switch call.Body[1] {
+				case "Id":
+					return []*dbus.Message{reply(call, dbus.MakeVariant(u.id))}
+				case "MainPID":
+					return []*dbus.Message{reply(call, dbus.MakeVariant(u.mainPID))}
+				}
+			}
+			return []*dbus.Message{errorReply(call, "org.freedesktop.DBus.Error.UnknownProperty", "Unknown property")}
+		}
+		return nil
+	})
+
+	tests := []struct {
+		pid  int
+		want string
+	}{
+		{100, "nginx.service"},
+		{101, ""}, // not the main process
+		{200, ""}, // not a service
+		{300, ""}, // infrastructure
+		{400, ""}, // no unit
+	}
+	for _, tt := range tests {
+		got, err := busDetect(context.Background(), tt.pid)
+		if err != nil || got != tt.want {
+			t.Errorf("busDetect(%d) = %q, %v; want %q", tt.pid, got, err, tt.want)
+		}
+	}
+}
+
+func TestIsMainPIDOfUnit(t *testing.T) {
+	const path = dbus.ObjectPath("/org/freedesktop/systemd1/unit/foot_2dserver_2eservice")
+	fakeBus(t, func(call *dbus.Message) []*dbus.Message {
+		switch member(call) {
+		case "GetUnit":
+			if call.Body[0] != "foot-server.service" {
+				return []*dbus.Message{errorReply(call, "org.freedesktop.systemd1.NoSuchUnit", "No such unit")}
+			}
+			return []*dbus.Message{reply(call, path)}
+		case "Get":
+			if objectPathOf(call) == path && call.Body[1] == "MainPID" {
+				return []*dbus.Message{reply(call, dbus.MakeVariant(uint32(500)))}
+			}
+			return []*dbus.Message{errorReply(call, "org.freedesktop.DBus.Error.UnknownProperty", "Unknown property")}
+		}
+		return nil
+	})
+
+	user := Scope{User: true, UID: os.Getuid()}
+	tests := []struct {
+		pid  int
+		unit string
+		want bool
+	}{
+		{500, "foot-server.service", true},
+		{501, "foot-server.service", false}, // a shell in the server's cgroup
+		{500, "missing.service", false},
+	}
+	for _, tt := range tests {
+		if got := isMainPIDOfUnit(tt.pid, tt.unit, user); got != tt.want {
+			t.Errorf("isMainPIDOfUnit(%d, %q) = %v, want %v", tt.pid, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestBelongsToUnit(t *testing.T) {
+	fakeBus(t, func(call *dbus.Message) []*dbus.Message {
+		switch member(call) {
+		case "GetUnit":
+			return []*dbus.Message{reply(call, dbus.ObjectPath("/org/freedesktop/systemd1/unit/x"))}
+		case "Get":
+			return []*dbus.Message{reply(call, dbus.MakeVariant(uint32(500)))}
+		}
+		return nil
+	})
+
+	user := Scope{User: true, UID: os.Getuid()}
+	tests := []struct {
+		name        string
+		pid         int
+		unit        string
+		scope       Scope
+		interactive bool
+		want        bool
+	}{
+		{"worker of a system service", 501, "nginx.service", Scope{}, false, true},
+		{"system service started from a shell", 501, "php-fpm.service", Scope{}, true, true},
+		{"worker of a user service", 501, "vite.service", user, false, true},
+		{"terminal server itself", 500, "foot-server.service", user, true, true},
+		{"dev server in a terminal server's tab", 501, "foot-server.service", user, true, false},
+	}
+	for _, tt := range tests {
+		if got := belongsToUnit(tt.pid, tt.unit, tt.scope, tt.interactive); got != tt.want {
+			t.Errorf("%s: belongsToUnit = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBusStop(t *testing.T) {
+	const job = dbus.ObjectPath("/org/freedesktop/systemd1/job/42")
+	tests := []struct {
+		name    string
+		scope   Scope
+		stop    func(call *dbus.Message) []*dbus.Message
+		wantErr string
+	}{
+		{
+			name: "done",
+			stop: func(call *dbus.Message) []*dbus.Message {
+				return []*dbus.Message{
+					reply(call, job),
+					jobRemoved("/org/freedesktop/systemd1/job/41", "other.service", "failed"),
+					jobRemoved(job, "nginx.service", "done"),
+				}
+			},
+		},
+		{
+			name:  "user unit",
+			scope: Scope{User: true, UID: os.Getuid()},
+			stop: func(call *dbus.Message) []*dbus.Message {
+				return []*dbus.Message{reply(call, job), jobRemoved(job, "nginx.service", "done")}
+			},
+		},
+		{
+			name: "failed",
+			stop: func(call *dbus.Message) []*dbus.Message {
+				return []*dbus.Message{reply(call, job), jobRemoved(job, "nginx.service", "failed")}
+			},
+			wantErr: "stopping nginx.service: job failed",
+		},
+		{
+			name: "no such unit",
+			stop: func(call *dbus.Message) []*dbus.Message {
+				return []*dbus.Message{errorReply(call, "org.freedesktop.systemd1.NoSuchUnit", "Unit nginx.service not loaded.")}
+			},
+			wantErr: "Unit nginx.service not loaded.",
+		},
+		{
+			name: "access denied",
+			stop: func(call *dbus.Message) []*dbus.Message {
+				return []*dbus.Message{errorReply(call, "org.freedesktop.DBus.Error.InteractiveAuthorizationRequired", "Interactive authentication required.")}
+			},
+			wantErr: "Interactive authentication required. (permission denied, try running with sudo)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subscribed bool
+			fakeBus(t, func(call *dbus.Message) []*dbus.Message {
+				switch member(call) {
+				case "Subscribe":
+					subscribed = true
+				case "StopUnit":
+					if !subscribed || call.Body[0] != "nginx.service" || call.Body[1] != "replace" {
+						return []*dbus.Message{errorReply(call, "test.Unexpected", "unexpected StopUnit")}
+					}
+					return tt.stop(call)
+				}
+				return nil
+			})
+
+			err := Stop("nginx.service", tt.scope)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Stop() = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Stop() = %v, want %q", err, tt.wantErr)
+			}
+			if denied := strings.Contains(tt.wantErr, "sudo"); errors.Is(err, os.ErrPermission) != denied {
+				t.Errorf("errors.Is(Stop(), os.ErrPermission) = %v, want %v", !denied, denied)
+			}
+		})
+	}
+}
+
+func TestBusUnreachable(t *testing.T) {
+	t.Setenv("DBUS_SYSTEM_BUS_ADDRESS", "unix:path="+filepath.Join(t.TempDir(), "missing.sock"))
+	closeSharedConns()
+	if _, err := busDetect(context.Background(), 1); !errors.Is(err, errBusUnreachable) {
+		t.Errorf("busDetect() = %v, want errBusUnreachable", err)
+	}
+	if _, err := busRestartSetting(context.Background(), "nginx.service", Scope{}); !errors.Is(err, errBusUnreachable) {
+		t.Errorf("busRestartSetting() = %v, want errBusUnreachable", err)
+	}
+}
+
+func TestBusSockets(t *testing.T) {
+	const cups = dbus.ObjectPath("/org/freedesktop/systemd1/unit/cups_2esocket")
+	const dbusSocket = dbus.ObjectPath("/org/freedesktop/systemd1/unit/dbus_2esocket")
+	fakeBus(t, func(call *dbus.Message) []*dbus.Message {
+		switch member(call) {
+		case "ListUnitsByPatterns":
+			return []*dbus.Message{reply(call, []unitStatus{
+				{Name: "cups.socket", ActiveState: "active", Path: cups, JobPath: "/"},
+				{Name: "dbus.socket", ActiveState: "active", Path: dbusSocket, JobPath: "/"},
+			})}
+		case "Get":
+			type listen struct{ Type, Address string }
+			switch [2]any{objectPathOf(call), call.Body[1]} {
+			case [2]any{cups, "Listen"}:
+				return []*dbus.Message{reply(call, dbus.MakeVariant([]listen{
+					{"Stream", "/run/cups/cups.sock"}, {"Stream", "[::]:631"},
+				}))}
+			case [2]any{cups, "Triggers"}:
+				return []*dbus.Message{reply(call, dbus.MakeVariant([]string{"cups.service"}))}
+			case [2]any{dbusSocket, "Listen"}:
+				return []*dbus.Message{reply(call, dbus.MakeVariant([]listen{{"Stream", "/run/dbus/system_bus_socket"}}))}
+			}
+			return []*dbus.Message{errorReply(call, "org.freedesktop.DBus.Error.UnknownProperty", "Unknown property")}
+		}
+		return nil
+	})
+
+	got, err := busSockets(context.Background(), Scope{})
+	want := []Socket{{Unit: "cups.socket", Activates: []string{"cups.service"}, listens: []socketListen{{"Stream", 631}}}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("busSockets() = %+v, %v; want %+v", got, err, want)
+	}
+}
@@ -2,9 +2,12 @@ package systemd
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -13,27 +16,82 @@ import (
 const detectionTimeout = 5 * time.Second
 const operationTimeout = 30 * time.Second
 
-// Detect checks if a process is managed by systemd and returns the unit name.
-// Returns empty string if not a systemd-managed process.
-func Detect(pid int) string {
+// userManagerRe matches the unit of a per-user service manager.
+var userManagerRe = regexp.MustCompile(`^user@(\d+)\.service$`)
+
+// Detect checks if a process is managed by systemd and returns the unit name
+// and the manager running it. Returns empty string if not a systemd-managed
+// process.
+func Detect(pid int) (string, Scope) {
 	// First try reading cgroup for systemd slice info
-	unit := detectFromCgroup(pid)
-	if unit != "" {
-		return unit
+	unit, scope := detectFromCgroup(pid)
+	if unit != "" && belongsToUnit(pid, unit, scope, hasTerminal(pid)) {
+		return unit, scope
+	}
+	// Fallback: ask systemd over D-Bus, or systemctl when the bus is unreachable
+	ctx, cancel := context.WithTimeout(context.Background(), detectionTimeout)
+	defer cancel()
+	unit, err := busDetect(ctx, pid)
+	if errors.Is(err, errBusUnreachable) {
+		return detectFromSystemctl(pid), Scope{}
+	}
+	return unit, Scope{}
+}
+
+// belongsToUnit reports whether pid, found in unit's cgroup, is part of the
+// service. Workers of a service (nginx or php-fpm workers, a listener under
+// an npm or shell wrapper) are. A dev server started in a terminal of a
+// user-manager terminal server (foot-server, tmux) shares that service's
+// cgroup but is not the service, so an interactive process there only
+// belongs to it as its main process.
+func belongsToUnit(pid int, unit string, scope Scope, interactive bool) bool {
+	if !scope.User || !interactive {
+		return true
+	}
+	return isMainPIDOfUnit(pid, unit, scope)
+}
+
+// hasTerminal reports whether pid has a controlling terminal, as processes
+// started from a shell do and service processes don't.
+func hasTerminal(pid int) bool {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+	return parseTTY(string(data)) != 0
+}
+
+// parseTTY returns the tty_nr field of /proc/<pid>/stat. The command name
+// in parentheses may hold spaces, so fields are counted after its end.
+func parseTTY(stat string) int {
+	i := strings.LastIndexByte(stat, ')')
+	if i < 0 {
+		return 0
+	}
+	// state ppid pgrp session tty_nr ...
+	fields := strings.Fields(stat[i+1:])
+	if len(fields) < 5 {
+		return 0
 	}
-	// Fallback: ask systemctl
-	return detectFromSystemctl(pid)
+	tty, _ := strconv.Atoi(fields[4])
+	return tty
 }
 
-func detectFromCgroup(pid int) string {
+func isServiceUnit(unit string) bool {
+	return strings.HasSuffix(unit, ".service")
+}
+
+func detectFromCgroup(pid int) (string, Scope) {
 	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cgroup"))
 	if err != nil {
-		return ""
+		return "", Scope{}
 	}
 	return parseCgroupUnit(string(data))
 }
 
-func parseCgroupUnit(content string) string {
+// parseCgroupUnit returns the first service in the cgroup path, and the
+// user manager it runs under, if any (user@UID.service/app.slice/x.service).
+func parseCgroupUnit(content string) (string, Scope) {
 	for _, line := range strings.Split(content, "\n") {
 		// Format: hierarchy-ID:controller-list:cgroup-path
 		parts := strings.SplitN(line, ":", 3)
@@ -42,18 +100,24 @@ func parseCgroupUnit(content string) string {
 		}
 		cgroupPath := parts[2]
 
+		var scope Scope
 		segments := strings.Split(cgroupPath, "/")
 		for _, seg := range segments {
-			if !strings.HasSuffix(seg, ".service") {
+			if m := userManagerRe.FindStringSubmatch(seg); m != nil {
+				uid, _ := strconv.Atoi(m[1])
+				scope = Scope{User: true, UID: uid}
+				continue
+			}
+			if !isServiceUnit(seg) {
 				continue
 			}
 			if isInfrastructureUnit(seg) {
 				continue
 			}
-			return seg
+			return seg, scope
 		}
 	}
-	return ""
+	return "", Scope{}
 }
 
 // isInfrastructureUnit returns true for systemd units that should never be stopped
@@ -93,12 +157,12 @@ func detectFromSystemctl(pid int) string {
 		line = strings.TrimLeft(line, "● ")
 		if strings.Contains(line, ".service") {
 			parts := strings.Fields(line)
-			if len(parts) > 0 && strings.HasSuffix(parts[0], ".service") {
+			if len(parts) > 0 && isServiceUnit(parts[0]) {
 				unit := parts[0]
 				if isInfrastructureUnit(unit) {
 					continue
 				}
-				if !isMainPIDOfUnit(pid, unit) {
+				if !isMainPIDOfUnit(pid, unit, Scope{}) {
 					continue
 				}
 				return unit
@@ -111,10 +175,13 @@ func detectFromSystemctl(pid int) string {
 
 // isMainPIDOfUnit checks whether the given PID is the main process of a systemd unit,
 // not just a descendant running inside its cgroup.
-func isMainPIDOfUnit(pid int, unit string) bool {
+func isMainPIDOfUnit(pid int, unit string, scope Scope) bool {
 	ctx, cancel := context.WithTimeout(context.Background(), detectionTimeout)
 	defer cancel()
-	cmd := exec.CommandContext(ctx, "systemctl", "show", "--property=MainPID", unit)
+	if mainPID, err := busMainPID(ctx, unit, scope); !errors.Is(err, errBusUnreachable) {
+		return err == nil && mainPID == uint32(pid)
+	}
+	cmd := exec.CommandContext(ctx, "systemctl", scope.systemctlArgs("show", "--property=MainPID", unit)...)
 	out, err := cmd.Output()
 	if err != nil {
 		return false
@@ -125,14 +192,61 @@ func isMainPIDOfUnit(pid int, unit string) bool {
 	return mainPID == strconv.Itoa(pid)
 }
 
-// Stop stops a systemd service.
-func Stop(unit string) error {
+// RestartSetting returns the unit's Restart= setting, e.g. "always",
+// "on-failure" or "no". Returns empty string if it can't be read.
+func RestartSetting(unit string, scope Scope) string {
+	ctx, cancel := context.WithTimeout(context.Background(), detectionTimeout)
+	defer cancel()
+	restart, err := busRestartSetting(ctx, unit, scope)
+	if !errors.Is(err, errBusUnreachable) {
+		return restart
+	}
+	cmd := exec.CommandContext(ctx, "systemctl", scope.systemctlArgs("show", "--property=Restart", unit)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	// Output is like "Restart=always"
+	return strings.TrimPrefix(strings.TrimSpace(string(out)), "Restart=")
+}
+
+// Stop stops a systemd service of the scope's manager and waits for the
+// stop job to finish.
+func Stop(unit string, scope Scope) error {
 	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
 	defer cancel()
-	cmd := exec.CommandContext(ctx, "systemctl", "stop", unit)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	err := busStop(ctx, unit, scope)
+	if errors.Is(err, errBusUnreachable) {
+		return stopWithSystemctl(ctx, unit, scope)
+	}
+	if needsPrivileges(err) {
+		return fmt.Errorf("%w (%w, try running with sudo)", err, os.ErrPermission)
+	}
+	return err
+}
+
+// stopWithSystemctl runs `systemctl stop`, capturing its output so nothing
+// is written behind the UI.
+func stopWithSystemctl(ctx context.Context, unit string, scope Scope) error {
+	out, err := exec.CommandContext(ctx, "systemctl", scope.systemctlArgs("stop", unit)...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			if deniedBySystemctl(msg) {
+				return fmt.Errorf("%s stop %s: %s (%w, try running with sudo)", scope.Systemctl(), unit, msg, os.ErrPermission)
+			}
+			return fmt.Errorf("%s stop %s: %s", scope.Systemctl(), unit, msg)
+		}
+		return fmt.Errorf("%s stop %s: %w", scope.Systemctl(), unit, err)
+	}
+	return nil
+}
+
+// deniedBySystemctl reports whether systemctl's output says the caller
+// lacks permission, e.g. "Failed to stop x.service: Access denied" or
+// "Interactive authentication required."
+func deniedBySystemctl(msg string) bool {
+	msg = strings.ToLower(msg)
+	return strings.Contains(msg, "access denied") || strings.Contains(msg, "authentication required")
 }
 
 // IsAvailable checks if systemd is running on this system.
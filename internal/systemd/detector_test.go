@@ -1,6 +1,8 @@
 package systemd
 
 import (
+	"fmt"
+	"os"
 	"testing"
 )
 
@@ -34,9 +36,10 @@ func TestIsInfrastructureUnit(t *testing.T) {
 
 func TestParseCgroupUnit(t *testing.T) {
 	tests := []struct {
-		name    string
-		content string
-		want    string
+		name      string
+		content   string
+		want      string
+		wantScope Scope
 	}{
 		{
 			name:    "nginx service",
@@ -44,9 +47,21 @@ func TestParseCgroupUnit(t *testing.T) {
 			want:    "nginx.service",
 		},
 		{
-			name:    "syncthing service",
-			content: "0::/user.slice/user-1000.slice/user@1000.service/app.slice/syncthing.service",
-			want:    "syncthing.service",
+			name:      "syncthing user service",
+			content:   "0::/user.slice/user-1000.slice/user@1000.service/app.slice/syncthing.service",
+			want:      "syncthing.service",
+			wantScope: Scope{User: true, UID: 1000},
+		},
+		{
+			name:      "root user service",
+			content:   "0::/user.slice/user-0.slice/user@0.service/app.slice/postgres.service",
+			want:      "postgres.service",
+			wantScope: Scope{User: true, UID: 0},
+		},
+		{
+			name:    "user manager itself",
+			content: "0::/user.slice/user-1000.slice/user@1000.service/init.scope",
+			want:    "",
 		},
 		{
 			name:    "skip docker runtime",
@@ -103,10 +118,60 @@ func TestParseCgroupUnit(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := parseCgroupUnit(tt.content)
-			if got != tt.want {
-				t.Errorf("parseCgroupUnit() = %q, want %q", got, tt.want)
+			got, scope := parseCgroupUnit(tt.content)
+			if got != tt.want || scope != tt.wantScope {
+				t.Errorf("parseCgroupUnit() = %q, %+v, want %q, %+v", got, scope, tt.want, tt.wantScope)
 			}
 		})
 	}
 }
+
+func TestParseTTY(t *testing.T) {
+	tests := []struct {
+		stat string
+		want int
+	}{
+		{"1234 (node) S 1200 1234 1200 34816 1234 4194304 0", 34816},
+		{"812 (nginx: worker) S 811 811 811 0 -1 4194624 0", 0},
+		{"77 (a) b) R 1 77 77 34817 77", 34817},
+		{"garbage", 0},
+	}
+	for _, tt := range tests {
+		if got := parseTTY(tt.stat); got != tt.want {
+			t.Errorf("parseTTY(%q) = %d, want %d", tt.stat, got, tt.want)
+		}
+	}
+}
+
+func TestDeniedBySystemctl(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want bool
+	}{
+		{"Failed to stop nginx.service: Access denied", true},
+		{"Failed to stop nginx.service: Interactive authentication required.\nSee system logs and 'systemctl status nginx.service' for details.", true},
+		{"Failed to stop nginx.service: Unit nginx.service not loaded.", false},
+	}
+	for _, tt := range tests {
+		if got := deniedBySystemctl(tt.msg); got != tt.want {
+			t.Errorf("deniedBySystemctl(%q) = %v, want %v", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestScopeSystemctl(t *testing.T) {
+	uid := os.Getuid()
+	tests := []struct {
+		scope Scope
+		want  string
+	}{
+		{Scope{}, "systemctl"},
+		{Scope{User: true, UID: uid}, "systemctl --user"},
+		{Scope{User: true, UID: uid + 1}, fmt.Sprintf("systemctl --user --machine=%d@", uid+1)},
+	}
+	for _, tt := range tests {
+		if got := tt.scope.Systemctl(); got != tt.want {
+			t.Errorf("%+v.Systemctl() = %q, want %q", tt.scope, got, tt.want)
+		}
+	}
+}
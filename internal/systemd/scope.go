@@ -0,0 +1,59 @@
+package systemd
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Scope tells which service manager runs a unit: the system manager, or
+// the per-user manager of user@UID.service.
+type Scope struct {
+	User bool
+	UID  int // owner of the user manager, set only for User
+}
+
+func (s Scope) String() string {
+	if s.User {
+		return "user"
+	}
+	return "system"
+}
+
+// Systemctl returns the systemctl invocation that talks to the scope's
+// manager, e.g. "systemctl --user".
+func (s Scope) Systemctl() string {
+	return strings.Join(append([]string{"systemctl"}, s.systemctlFlags()...), " ")
+}
+
+// systemctlArgs prepends the scope's flags to a systemctl command line.
+func (s Scope) systemctlArgs(args ...string) []string {
+	return append(s.systemctlFlags(), args...)
+}
+
+// systemctlFlags selects the user manager; another user's manager is
+// reached through --machine=UID@, which needs root.
+func (s Scope) systemctlFlags() []string {
+	if !s.User {
+		return nil
+	}
+	if s.UID == os.Getuid() {
+		return []string{"--user"}
+	}
+	return []string{"--user", "--machine=" + strconv.Itoa(s.UID) + "@"}
+}
+
+// busAddress returns the address of the bus the scope's manager listens
+// on: the system bus, or the user's session bus.
+func (s Scope) busAddress() string {
+	if !s.User {
+		if addr := os.Getenv("DBUS_SYSTEM_BUS_ADDRESS"); addr != "" {
+			return addr
+		}
+		return defaultBusAddress
+	}
+	if addr := os.Getenv("DBUS_SESSION_BUS_ADDRESS"); addr != "" && s.UID == os.Getuid() {
+		return addr
+	}
+	return "unix:path=/run/user/" + strconv.Itoa(s.UID) + "/bus"
+}
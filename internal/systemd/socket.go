@@ -0,0 +1,227 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const socketInterface = "org.freedesktop.systemd1.Socket"
+
+// Socket is a .socket unit: its service manager holds the listening
+// sockets and starts the units it activates on the first connection.
+type Socket struct {
+	Unit      string
+	Scope     Scope
+	Activates []string // e.g. "cups.service"
+	listens   []socketListen
+}
+
+// socketListen is one entry of a socket unit's Listen property.
+type socketListen struct {
+	typ  string // "Stream", "Datagram", ...
+	port int
+}
+
+// Listens reports whether the socket unit listens on port. protocol is a
+// port.Listener protocol such as "tcp6".
+func (s Socket) Listens(port int, protocol string) bool {
+	typ := "Stream"
+	if strings.HasPrefix(protocol, "udp") {
+		typ = "Datagram"
+	}
+	for _, l := range s.listens {
+		if l.port == port && l.typ == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// ActivatesUnit reports whether unit is started by the socket.
+func (s Socket) ActivatesUnit(unit string) bool {
+	for _, u := range s.Activates {
+		if u == unit {
+			return true
+		}
+	}
+	return false
+}
+
+// ManagerScope reports whether pid is a systemd service manager: PID 1, or
+// the systemd --user instance of user@UID.service.
+func ManagerScope(pid int) (Scope, bool) {
+	if pid == 1 {
+		return Scope{}, true
+	}
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return Scope{}, false
+	}
+	return parseManagerCgroup(string(data))
+}
+
+// parseManagerCgroup recognizes the cgroup of a user manager,
+// ".../user@UID.service/init.scope".
+func parseManagerCgroup(content string) (Scope, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		segments := strings.Split(parts[2], "/")
+		n := len(segments)
+		if n < 2 || segments[n-1] != "init.scope" {
+			continue
+		}
+		if m := userManagerRe.FindStringSubmatch(segments[n-2]); m != nil {
+			uid, _ := strconv.Atoi(m[1])
+			return Scope{User: true, UID: uid}, true
+		}
+	}
+	return Scope{}, false
+}
+
+// Sockets lists the active socket units of the scope's manager that
+// listen on an IP port.
+func Sockets(scope Scope) []Socket {
+	ctx, cancel := context.WithTimeout(context.Background(), detectionTimeout)
+	defer cancel()
+	sockets, err := busSockets(ctx, scope)
+	if !errors.Is(err, errBusUnreachable) {
+		return sockets
+	}
+	cmd := exec.CommandContext(ctx, "systemctl", scope.systemctlArgs("list-sockets", "--no-legend", "--no-pager", "--full", "--show-types")...)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	return parseListSockets(string(out), scope)
+}
+
+// unitStatus is one entry of ListUnitsByPatterns' reply.
+type unitStatus struct {
+	Name, Description, LoadState, ActiveState, SubState, Following string
+	Path                                                           dbus.ObjectPath
+	JobID                                                          uint32
+	JobType                                                        string
+	JobPath                                                        dbus.ObjectPath
+}
+
+// busSockets lists socket units the way `systemctl list-sockets` does:
+// every active *.socket unit, then its Listen and Triggers properties.
+func busSockets(ctx context.Context, scope Scope) ([]Socket, error) {
+	var sockets []Socket
+	err := withBus(ctx, scope, func(c *dbus.Conn) error {
+		var units []unitStatus
+		err := c.Object(systemdService, systemdPath).CallWithContext(ctx, managerInterface+".ListUnitsByPatterns", 0,
+			[]string{"active"}, []string{"*.socket"}).Store(&units)
+		if err != nil {
+			return err
+		}
+		for _, u := range units {
+			sock := Socket{Unit: u.Name, Scope: scope}
+
+			var listen []struct{ Type, Address string }
+			if err := getProperty(ctx, c, u.Path, socketInterface, "Listen", &listen); err != nil {
+				return err
+			}
+			for _, l := range listen {
+				if port, ok := listenPort(l.Address); ok {
+					sock.listens = append(sock.listens, socketListen{l.Type, port})
+				}
+			}
+			if len(sock.listens) == 0 {
+				continue
+			}
+
+			if err := getProperty(ctx, c, u.Path, unitInterface, "Triggers", &sock.Activates); err != nil {
+				return err
+			}
+			sockets = append(sockets, sock)
+		}
+		return nil
+	})
+	return sockets, err
+}
+
+// parseListSockets parses `systemctl list-sockets --show-types` output:
+// LISTEN TYPE UNIT ACTIVATES..., one row per listening address.
+func parseListSockets(out string, scope Scope) []Socket {
+	var sockets []Socket
+	index := make(map[string]int)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		port, ok := listenPort(fields[0])
+		if !ok {
+			continue
+		}
+		unit := fields[2]
+		i, seen := index[unit]
+		if !seen {
+			i = len(sockets)
+			index[unit] = i
+			sock := Socket{Unit: unit, Scope: scope}
+			for _, a := range fields[3:] {
+				if a = strings.TrimSuffix(a, ","); a != "" {
+					sock.Activates = append(sock.Activates, a)
+				}
+			}
+			sockets = append(sockets, sock)
+		}
+		sockets[i].listens = append(sockets[i].listens, socketListen{fields[1], port})
+	}
+	return sockets
+}
+
+// listenPort extracts the port of an IP listen address such as
+// "0.0.0.0:80" or "[::]:8080". Unix socket paths have none.
+func listenPort(addr string) (int, bool) {
+	if strings.HasPrefix(addr, "/") || strings.HasPrefix(addr, "@") {
+		return 0, false
+	}
+	i := strings.LastIndex(addr, ":")
+	if i < 0 {
+		return 0, false
+	}
+	port, err := strconv.Atoi(addr[i+1:])
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, false
+	}
+	return port, true
+}
+
+// Units returns the units StopSocket stops, in order: the socket first so
+// a new connection cannot start the others again.
+func (s Socket) Units() []string {
+	units := []string{s.Unit}
+	for _, unit := range s.Activates {
+		// Accept=yes sockets name a template; its instances stop with the socket
+		if !strings.HasSuffix(unit, "@.service") {
+			units = append(units, unit)
+		}
+	}
+	return units
+}
+
+// StopSocket stops a socket unit, then the units it activates.
+func StopSocket(s Socket) error {
+	units := s.Units()
+	if err := Stop(units[0], s.Scope); err != nil {
+		return err
+	}
+	var errs []error
+	for _, unit := range units[1:] {
+		errs = append(errs, Stop(unit, s.Scope))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,119 @@
+package systemd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseManagerCgroup(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Scope
+		wantOK  bool
+	}{
+		{
+			name:    "user manager",
+			content: "0::/user.slice/user-1000.slice/user@1000.service/init.scope",
+			want:    Scope{User: true, UID: 1000},
+			wantOK:  true,
+		},
+		{
+			name:    "user service",
+			content: "0::/user.slice/user-1000.slice/user@1000.service/app.slice/syncthing.service",
+		},
+		{
+			name:    "system service",
+			content: "0::/system.slice/nginx.service",
+		},
+		{
+			name:    "empty",
+			content: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseManagerCgroup(tt.content)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseManagerCgroup() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestListenPort(t *testing.T) {
+	tests := []struct {
+		addr   string
+		want   int
+		wantOK bool
+	}{
+		{"0.0.0.0:80", 80, true},
+		{"127.0.0.1:631", 631, true},
+		{"[::]:8080", 8080, true},
+		{"/run/dbus/system_bus_socket", 0, false},
+		{"@/org/kernel/linux/storage", 0, false},
+		{"route 1361", 0, false},
+		{"[::]:99999", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := listenPort(tt.addr)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("listenPort(%q) = %d, %v, want %d, %v", tt.addr, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestParseListSockets(t *testing.T) {
+	out := `/run/dbus/system_bus_socket  Stream    dbus.socket   dbus.service
+[::]:22                      Stream    sshd.socket   sshd.service
+0.0.0.0:5353                 Datagram  mdns.socket   mdns.service, mdns-helper.service
+127.0.0.1:5353               Datagram  mdns.socket   mdns.service, mdns-helper.service
+[::]:9090                    Stream    cockpit.socket cockpit@.service
+`
+	got := parseListSockets(out, Scope{})
+	want := []Socket{
+		{Unit: "sshd.socket", Activates: []string{"sshd.service"}, listens: []socketListen{{"Stream", 22}}},
+		{Unit: "mdns.socket", Activates: []string{"mdns.service", "mdns-helper.service"},
+			listens: []socketListen{{"Datagram", 5353}, {"Datagram", 5353}}},
+		{Unit: "cockpit.socket", Activates: []string{"cockpit@.service"}, listens: []socketListen{{"Stream", 9090}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseListSockets() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSocketListens(t *testing.T) {
+	s := Socket{Unit: "mdns.socket", listens: []socketListen{{"Stream", 22}, {"Datagram", 5353}}}
+	tests := []struct {
+		port     int
+		protocol string
+		want     bool
+	}{
+		{22, "tcp", true},
+		{22, "tcp6", true},
+		{22, "udp", false},
+		{5353, "udp6", true},
+		{80, "tcp", false},
+	}
+	for _, tt := range tests {
+		if got := s.Listens(tt.port, tt.protocol); got != tt.want {
+			t.Errorf("Listens(%d, %q) = %v, want %v", tt.port, tt.protocol, got, tt.want)
+		}
+	}
+}
+
+func TestSocketUnits(t *testing.T) {
+	tests := []struct {
+		socket Socket
+		want   []string
+	}{
+		{Socket{Unit: "sshd.socket", Activates: []string{"sshd.service"}}, []string{"sshd.socket", "sshd.service"}},
+		{Socket{Unit: "cockpit.socket", Activates: []string{"cockpit@.service"}}, []string{"cockpit.socket"}},
+		{Socket{Unit: "idle.socket"}, []string{"idle.socket"}},
+	}
+	for _, tt := range tests {
+		if got := tt.socket.Units(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s.Units() = %v, want %v", tt.socket.Unit, got, tt.want)
+		}
+	}
+}
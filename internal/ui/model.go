@@ -2,8 +2,11 @@ package ui
 
 import (
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -22,31 +25,90 @@ const (
 	stateList
 	stateConfirm
 	stateResult
+	stateLog
 )
 
 const autoRefreshInterval = 2 * time.Second
 
+// statusTTL is how long a kill result stays in the status line.
+const statusTTL = 6 * time.Second
+
 type tickMsg time.Time
 
 type processItem struct {
-	listener port.Listener
-	context  process.Context
+	context process.Context
+}
+
+// key identifies a row across refreshes: its PID, or its unit for a socket
+// unit row, whose PID is the service manager shared with other rows.
+// Owner-unknown rows have no key.
+func (item processItem) key() string {
+	if s := item.context.SocketUnit; s != nil {
+		return s.Scope.String() + ":" + s.Unit
+	}
+	if item.context.OwnerUnknown {
+		return ""
+	}
+	return strconv.Itoa(item.context.Info.PID)
 }
 
 // Model is the Bubble Tea model for the zap TUI.
 type Model struct {
-	state       state
-	queries     []port.Query // nil/empty means show all ports
-	items       []processItem
-	cursor      int
-	selectedPID int // PID of selected row — used to restore cursor after refresh
-	force       bool
-	message     string
-	isError     bool
-	width       int
-	height      int
-	quitting    bool
-	search      string
+	state     state
+	queries   []port.Query // nil/empty means show all ports
+	items     []processItem
+	cursor    int
+	selected  string          // key of selected row — used to restore cursor after refresh
+	marked    map[string]bool // keys of rows marked for a batch kill
+	opts      Options
+	message   string // fatal load error shown in stateResult
+	isError   bool
+	status    statusLine // transient kill status above the table
+	pending   int        // kills still running
+	log       []logEntry // every kill outcome this session
+	logOffset int        // first visible log line in stateLog
+
+	// Strategy and signal picked in the confirm dialog
+	pickStrategy kill.Strategy
+	pickSignal   syscall.Signal
+	width        int
+	height       int
+	quitting     bool
+	search       string
+
+	// frozen holds the resume action of every row paused this session,
+	// until it is resumed or gone, so a refresh can't lose track of it
+	frozen     map[string]kill.Action
+	quitWarned bool // quitting with frozen rows was refused once
+
+	// confirmAffected holds affected() of each confirm action. Listing
+	// cgroups, trees and process groups scans /proc, so it is done when the
+	// dialog opens or its strategy changes rather than on every render
+	confirmAffected []affectedProcs
+
+	// containers is refreshed for every load, keeping the restart policies
+	// it inspected
+	containers *container.Snapshot
+}
+
+// affectedProcs is the result of affected for one action.
+type affectedProcs struct {
+	label string
+	n     int
+}
+
+// statusLine is a transient message shown above the table.
+type statusLine struct {
+	text    string
+	isError bool
+	expires time.Time // zero while a kill is still running
+}
+
+// logEntry records one kill outcome for the session log.
+type logEntry struct {
+	at      time.Time
+	text    string
+	isError bool
 }
 
 // visibleItems returns the filtered subset of items matching m.search.
@@ -56,20 +118,286 @@ func (m Model) visibleItems() []processItem {
 	}
 	var out []processItem
 	for _, item := range m.items {
-		if strings.Contains(strconv.Itoa(item.listener.Port), m.search) {
-			out = append(out, item)
+		for _, b := range item.context.Info.Ports {
+			if strings.Contains(strconv.Itoa(b.Port), m.search) {
+				out = append(out, item)
+				break
+			}
 		}
 	}
 	return out
 }
 
+// Options configures how the TUI kills processes.
+type Options struct {
+	Force       bool           // SIGKILL / container kill instead of a graceful stop
+	Escalate    bool           // escalate to a forceful kill after GracePeriod
+	GracePeriod time.Duration  // zero means kill.DefaultGracePeriod
+	Strategy    *kill.Strategy // nil means kill.RecommendedStrategy
+	Signal      syscall.Signal // zero means SIGTERM (SIGKILL with Force)
+}
+
 // New creates a new TUI model. queries is nil/empty to show all ports.
-func New(queries []port.Query, force bool) Model {
+func New(queries []port.Query, opts Options) Model {
 	return Model{
 		state:   stateLoading,
 		queries: queries,
-		force:   force,
+		opts:    opts,
+
+		containers: container.NewSnapshot(),
+	}
+}
+
+// markable reports whether a row can be marked and killed.
+func markable(item processItem) bool {
+	return !item.context.OwnerUnknown
+}
+
+// toggleMark marks or unmarks a row for a batch kill.
+func (m *Model) toggleMark(item processItem) {
+	if !markable(item) {
+		return
+	}
+	key := item.key()
+	if m.marked[key] {
+		delete(m.marked, key)
+		return
 	}
+	if m.marked == nil {
+		m.marked = make(map[string]bool)
+	}
+	m.marked[key] = true
+}
+
+// toggleMarkAll marks every visible row, or clears the marks if all visible
+// rows are already marked.
+func (m *Model) toggleMarkAll() {
+	visible := m.visibleItems()
+	all := true
+	for _, item := range visible {
+		if markable(item) && !m.marked[item.key()] {
+			all = false
+			break
+		}
+	}
+	if all {
+		for _, item := range visible {
+			delete(m.marked, item.key())
+		}
+		return
+	}
+	for _, item := range visible {
+		if markable(item) && !m.marked[item.key()] {
+			m.toggleMark(item)
+		}
+	}
+}
+
+// pruneMarks drops marks for processes that are no longer listed.
+func (m *Model) pruneMarks() {
+	if len(m.marked) == 0 {
+		return
+	}
+	present := make(map[string]bool, len(m.items))
+	for _, item := range m.items {
+		present[item.key()] = true
+	}
+	for key := range m.marked {
+		if !present[key] {
+			delete(m.marked, key)
+		}
+	}
+}
+
+// targets returns the rows to kill: every marked row, or the row under the
+// cursor when nothing is marked.
+func (m Model) targets() []processItem {
+	if len(m.marked) > 0 {
+		var out []processItem
+		for _, item := range m.items {
+			if m.marked[item.key()] {
+				out = append(out, item)
+			}
+		}
+		return out
+	}
+	visible := m.visibleItems()
+	if m.cursor < len(visible) && markable(visible[m.cursor]) {
+		return []processItem{visible[m.cursor]}
+	}
+	return nil
+}
+
+// action builds the kill action for a process using the model's options.
+// Frozen processes default to being resumed.
+func (m Model) action(ctx process.Context) kill.Action {
+	strategy, _ := kill.ChooseStrategy(ctx, m.opts.Strategy)
+	if ctx.Frozen && m.opts.Strategy == nil {
+		strategy = kill.StrategyResume
+	}
+	return kill.Action{
+		Strategy:    strategy,
+		Context:     ctx,
+		Force:       m.opts.Force,
+		Signal:      m.opts.Signal,
+		Escalate:    m.opts.Escalate,
+		GracePeriod: m.opts.GracePeriod,
+	}
+}
+
+// confirmActions returns the actions for the confirm dialog's targets with
+// the strategy and signal picked in the dialog applied.
+func (m Model) confirmActions() []kill.Action {
+	targets := m.targets()
+	actions := make([]kill.Action, len(targets))
+	for i, item := range targets {
+		actions[i] = m.action(item.context)
+		if len(targets) == 1 {
+			actions[i].Strategy = m.pickStrategy
+		}
+		if actions[i].Strategy.UsesSignal() {
+			actions[i].Signal = m.pickSignal
+		}
+	}
+	return actions
+}
+
+// picksSignal reports whether any confirm action sends the picked signal,
+// so that `s` has something to change.
+func (m Model) picksSignal() bool {
+	for _, action := range m.confirmActions() {
+		if action.Strategy.UsesSignal() {
+			return true
+		}
+	}
+	return false
+}
+
+// listAffected fills m.confirmAffected for the current confirm actions.
+func (m *Model) listAffected() {
+	actions := m.confirmActions()
+	m.confirmAffected = make([]affectedProcs, len(actions))
+	for i, action := range actions {
+		label, n := affected(action)
+		m.confirmAffected[i] = affectedProcs{label, n}
+	}
+}
+
+// affectedAt returns the processes the i-th confirm action affects, as
+// listed by listAffected.
+func (m Model) affectedAt(i int) (label string, n int) {
+	if i >= len(m.confirmAffected) {
+		return "", 0
+	}
+	return m.confirmAffected[i].label, m.confirmAffected[i].n
+}
+
+// cycleStrategy moves the single confirm target to its next available strategy.
+func (m *Model) cycleStrategy() {
+	targets := m.targets()
+	if len(targets) != 1 {
+		return
+	}
+	strategies := kill.AvailableStrategies(targets[0].context)
+	for i, s := range strategies {
+		if s == m.pickStrategy {
+			m.pickStrategy = strategies[(i+1)%len(strategies)]
+			return
+		}
+	}
+	m.pickStrategy = strategies[0]
+}
+
+// cycleSignal moves to the next signal in kill.Signals.
+func (m *Model) cycleSignal() {
+	current := m.pickSignal
+	if current == 0 {
+		current = syscall.SIGTERM
+		if m.opts.Force {
+			current = syscall.SIGKILL
+		}
+	}
+	next := kill.Signals[0]
+	for i, sig := range kill.Signals {
+		if sig == current {
+			next = kill.Signals[(i+1)%len(kill.Signals)]
+			break
+		}
+	}
+	m.pickSignal = next
+}
+
+// recordFrozen remembers a row paused by action, and forgets it once it
+// is resumed or killed.
+func (m *Model) recordFrozen(action kill.Action) {
+	key := processItem{context: action.Context}.key()
+	if key == "" {
+		return
+	}
+	if action.Strategy != kill.StrategyPause {
+		delete(m.frozen, key)
+		return
+	}
+	if m.frozen == nil {
+		m.frozen = make(map[string]kill.Action)
+	}
+	resume := action
+	resume.Strategy = kill.StrategyResume
+	resume.Context.Frozen = true
+	m.frozen[key] = resume
+}
+
+// trackFrozen marks remembered rows as frozen after a refresh, even when
+// the pause can't be detected (e.g. a paused container behind
+// docker-proxy), and forgets rows that are gone.
+func (m *Model) trackFrozen() {
+	if len(m.frozen) == 0 {
+		return
+	}
+	present := make(map[string]bool, len(m.items))
+	for i := range m.items {
+		key := m.items[i].key()
+		resume, ok := m.frozen[key]
+		if !ok {
+			continue
+		}
+		present[key] = true
+		m.items[i].context.Frozen = true
+		resume.Context = m.items[i].context
+		m.frozen[key] = resume
+	}
+	for key := range m.frozen {
+		if !present[key] {
+			delete(m.frozen, key)
+		}
+	}
+}
+
+// Frozen returns the resume action of every row paused in the TUI and not
+// resumed yet, so the caller can remind the user after quitting.
+func (m Model) Frozen() []kill.Action {
+	var actions []kill.Action
+	for _, item := range m.items {
+		if resume, ok := m.frozen[item.key()]; ok {
+			actions = append(actions, resume)
+		}
+	}
+	return actions
+}
+
+// quit exits the TUI, but warns once first while rows are still frozen.
+func (m Model) quit() (tea.Model, tea.Cmd) {
+	if n := len(m.frozen); n > 0 && !m.quitWarned {
+		m.quitWarned = true
+		m.status = statusLine{
+			text:    fmt.Sprintf("%d still frozen — enter on a frozen row resumes it, C-g again quits", n),
+			isError: true,
+			expires: time.Now().Add(statusTTL),
+		}
+		return m, nil
+	}
+	m.quitting = true
+	return m, tea.Quit
 }
 
 // Messages
@@ -79,9 +407,24 @@ type loadedMsg struct {
 	err   error
 }
 
+// killOutcome is the result of killing one target.
+type killOutcome struct {
+	action kill.Action
+	desc   string
+	result kill.Result
+	err    error
+}
+
+// String summarises the outcome for the status line and session log.
+func (o killOutcome) String() string {
+	if o.err != nil {
+		return fmt.Sprintf("Failed: %s — %v", o.desc, o.err)
+	}
+	return fmt.Sprintf("Done: %s — %s", o.desc, o.result)
+}
+
 type killResultMsg struct {
-	desc string
-	err  error
+	outcomes []killOutcome
 }
 
 // Commands
@@ -92,7 +435,7 @@ func tickCmd() tea.Cmd {
 	})
 }
 
-func loadProcesses(queries []port.Query) tea.Cmd {
+func loadProcesses(queries []port.Query, containers *container.Snapshot) tea.Cmd {
 	return func() tea.Msg {
 		var allListeners []port.Listener
 		var err error
@@ -112,46 +455,39 @@ func loadProcesses(queries []port.Query) tea.Cmd {
 			}
 		}
 
-		// Deduplicate by PID and gather context
-		seen := make(map[int]bool)
+		// One row per process with every port it holds, or per socket unit
 		var items []processItem
-		for _, l := range allListeners {
-			if seen[l.PID] {
-				continue
-			}
-			seen[l.PID] = true
-
-			ctx, err := process.GatherContext(l.PID, l.Port)
-			if err != nil {
-				continue
-			}
-			items = append(items, processItem{
-				listener: l,
-				context:  ctx,
-			})
+		contexts, _ := process.GatherListeners(allListeners, containers.Refresh())
+		for _, ctx := range contexts {
+			items = append(items, processItem{context: ctx})
 		}
 
 		return loadedMsg{items: items}
 	}
 }
 
-func executeKill(item processItem, force bool) tea.Cmd {
+// executeKill runs every action concurrently and reports all outcomes in
+// the original order.
+func executeKill(actions []kill.Action) tea.Cmd {
 	return func() tea.Msg {
-		strategy := kill.RecommendedStrategy(item.context)
-		action := kill.Action{
-			Strategy: strategy,
-			Context:  item.context,
-			Force:    force,
+		outcomes := make([]killOutcome, len(actions))
+		var wg sync.WaitGroup
+		for i, action := range actions {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := kill.ExecuteAndVerify(action)
+				outcomes[i] = killOutcome{action: action, desc: kill.Describe(action), result: result, err: err}
+			}()
 		}
-		desc := kill.Describe(action)
-		err := kill.Execute(action)
-		return killResultMsg{desc: desc, err: err}
+		wg.Wait()
+		return killResultMsg{outcomes: outcomes}
 	}
 }
 
 // Init starts the initial loading.
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(loadProcesses(m.queries), tickCmd())
+	return tea.Batch(loadProcesses(m.queries, m.containers), tickCmd())
 }
 
 // Update handles events.
@@ -166,12 +502,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleKey(msg)
 
 	case tickMsg:
-		if m.state == stateList {
+		if !m.status.expires.IsZero() && time.Time(msg).After(m.status.expires) {
+			m.status = statusLine{}
+		}
+		if m.state == stateList || m.state == stateLog {
 			visible := m.visibleItems()
 			if m.cursor < len(visible) {
-				m.selectedPID = visible[m.cursor].context.Info.PID
+				m.selected = visible[m.cursor].key()
 			}
-			return m, tea.Batch(loadProcesses(m.queries), tickCmd())
+			return m, tea.Batch(loadProcesses(m.queries, m.containers), tickCmd())
 		}
 		return m, tickCmd()
 
@@ -186,20 +525,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.isError = true
 			return m, nil
 		}
-		if len(msg.items) == 0 {
-			m.state = stateResult
-			m.message = "No processes found."
-			m.isError = false
-			return m, nil
-		}
 		m.items = msg.items
-		m.state = stateList
-		// Restore cursor by PID within visible (filtered) items; fall back to first
+		if m.state != stateLog {
+			m.state = stateList
+		}
+		m.pruneMarks()
+		m.trackFrozen()
+		// Restore cursor by row key within visible (filtered) items; fall back to first
 		visible := m.visibleItems()
-		if m.selectedPID != 0 {
+		if m.selected != "" {
 			m.cursor = 0
 			for i, item := range visible {
-				if item.context.Info.PID == m.selectedPID {
+				if item.key() == m.selected {
 					m.cursor = i
 					break
 				}
@@ -208,22 +545,41 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.cursor >= len(visible) {
 			m.cursor = max(0, len(visible)-1)
 		}
-		// Keep selectedPID in sync with actual cursor
+		// Keep selected in sync with actual cursor
 		if m.cursor < len(visible) {
-			m.selectedPID = visible[m.cursor].context.Info.PID
+			m.selected = visible[m.cursor].key()
 		}
 		return m, nil
 
 	case killResultMsg:
-		m.state = stateResult
-		if msg.err != nil {
-			m.message = fmt.Sprintf("Failed: %s — %v", msg.desc, msg.err)
-			m.isError = true
-		} else {
-			m.message = fmt.Sprintf("Done: %s", msg.desc)
-			m.isError = false
+		m.pending--
+		now := time.Now()
+		failed := 0
+		for _, o := range msg.outcomes {
+			entry := logEntry{at: now, text: o.String(), isError: o.err != nil}
+			m.log = append(m.log, entry)
+			if o.err != nil {
+				failed++
+				continue
+			}
+			m.recordFrozen(o.action)
 		}
-		return m, nil
+		var text string
+		switch {
+		case len(msg.outcomes) == 1:
+			text = msg.outcomes[0].String()
+		case failed > 0:
+			text = fmt.Sprintf("%d of %d kills failed — C-l for details", failed, len(msg.outcomes))
+		default:
+			text = fmt.Sprintf("Done: killed %d processes", len(msg.outcomes))
+		}
+		m.status = statusLine{text: text, isError: failed > 0, expires: now.Add(statusTTL)}
+		if m.pending > 0 {
+			// Another kill is still running; keep the status visible
+			m.status.expires = time.Time{}
+		}
+		// Refresh right away so killed processes disappear
+		return m, loadProcesses(m.queries, m.containers)
 	}
 
 	return m, nil
@@ -232,17 +588,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch m.state {
 	case stateList:
+		if key := msg.String(); key != "ctrl+g" && key != "esc" {
+			m.quitWarned = false
+		}
 		switch msg.String() {
-		case "ctrl+g", "ctrl+c":
+		case "ctrl+c":
 			m.quitting = true
 			return m, tea.Quit
+		case "ctrl+g":
+			return m.quit()
 		case "esc":
 			if m.search != "" {
 				m.search = ""
 				m.cursor = 0
 			} else {
-				m.quitting = true
-				return m, tea.Quit
+				return m.quit()
 			}
 		case "0", "1", "2", "3", "4", "5", "6", "7", "8", "9":
 			m.search += msg.String()
@@ -258,7 +618,7 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.cursor--
 			}
 			if m.cursor < len(visible) {
-				m.selectedPID = visible[m.cursor].context.Info.PID
+				m.selected = visible[m.cursor].key()
 			}
 		case "down", "ctrl+n":
 			visible := m.visibleItems()
@@ -266,28 +626,58 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.cursor++
 			}
 			if m.cursor < len(visible) {
-				m.selectedPID = visible[m.cursor].context.Info.PID
+				m.selected = visible[m.cursor].key()
+			}
+		case " ", "tab":
+			visible := m.visibleItems()
+			if m.cursor < len(visible) {
+				m.toggleMark(visible[m.cursor])
+			}
+			if m.cursor < len(visible)-1 {
+				m.cursor++
+				m.selected = visible[m.cursor].key()
 			}
-		case "enter", " ":
-			if m.cursor < len(m.visibleItems()) {
+		case "ctrl+a":
+			m.toggleMarkAll()
+		case "enter":
+			if targets := m.targets(); len(targets) > 0 {
 				m.state = stateConfirm
+				m.pickStrategy = m.action(targets[0].context).Strategy
+				m.pickSignal = m.opts.Signal
+				m.listAffected()
 			}
+		case "ctrl+l":
+			m.state = stateLog
+			m.logOffset = max(0, len(m.log)-m.logLines())
 		case "ctrl+r":
 			visible := m.visibleItems()
 			if m.cursor < len(visible) {
-				m.selectedPID = visible[m.cursor].context.Info.PID
+				m.selected = visible[m.cursor].key()
 			}
 			m.state = stateLoading
-			return m, loadProcesses(m.queries)
+			return m, loadProcesses(m.queries, m.containers)
 		}
 
 	case stateConfirm:
 		switch msg.String() {
 		case "y", "Y", "enter":
-			item := m.visibleItems()[m.cursor]
-			m.state = stateLoading
-			m.message = "Killing..."
-			return m, executeKill(item, m.force)
+			actions := m.confirmActions()
+			m.state = stateList
+			m.marked = nil
+			m.pending++
+			text := "Killing: " + kill.Describe(actions[0]) + "..."
+			if len(actions) > 1 {
+				text = fmt.Sprintf("Killing %d processes...", len(actions))
+			}
+			m.status = statusLine{text: text}
+			return m, executeKill(actions)
+		case "tab":
+			m.cycleStrategy()
+			m.listAffected()
+		case "s":
+			if m.picksSignal() {
+				m.cycleSignal()
+			}
 		case "n", "N", "esc", "ctrl+g":
 			m.state = stateList
 		case "ctrl+c":
@@ -295,6 +685,23 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 
+	case stateLog:
+		switch msg.String() {
+		case "up", "ctrl+p":
+			if m.logOffset > 0 {
+				m.logOffset--
+			}
+		case "down", "ctrl+n":
+			if m.logOffset < len(m.log)-m.logLines() {
+				m.logOffset++
+			}
+		case "esc", "ctrl+l", "ctrl+g":
+			m.state = stateList
+		case "ctrl+c":
+			m.quitting = true
+			return m, tea.Quit
+		}
+
 	case stateResult:
 		switch msg.String() {
 		case "ctrl+g", "ctrl+c", "esc", "enter":
@@ -303,7 +710,7 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		case "ctrl+b":
 			m.state = stateLoading
 			m.cursor = 0
-			return m, loadProcesses(m.queries)
+			return m, loadProcesses(m.queries, m.containers)
 		}
 	}
 
@@ -325,6 +732,8 @@ func (m Model) View() string {
 		return m.viewConfirm()
 	case stateResult:
 		return m.viewResult()
+	case stateLog:
+		return m.viewLog()
 	}
 	return ""
 }
@@ -340,6 +749,7 @@ func (m Model) viewLoading() string {
 func (m Model) viewList() string {
 	title := m.buildTitle()
 	search := m.buildSearchBar()
+	status := m.buildStatusLine()
 	tbl := m.buildTable()
 	detail := m.buildDetailPanel()
 	help := m.buildHelp()
@@ -347,6 +757,7 @@ func (m Model) viewList() string {
 	return lipgloss.JoinVertical(lipgloss.Left,
 		titleStyle.Render(title),
 		search,
+		status,
 		tbl,
 		detail,
 		help,
@@ -357,16 +768,25 @@ func (m Model) viewList() string {
 func (m Model) viewConfirm() string {
 	title := m.buildTitle()
 	search := m.buildSearchBar()
+	status := m.buildStatusLine()
 	tbl := m.buildTable()
 	confirm := m.buildConfirmPrompt()
-	help := helpStyle.Render("y/enter confirm • n/esc cancel")
+	help := "y/enter confirm"
+	if len(m.targets()) == 1 {
+		help += " • tab strategy"
+	}
+	if m.picksSignal() {
+		help += " • s signal"
+	}
+	help += " • n/esc cancel"
 
 	return lipgloss.JoinVertical(lipgloss.Left,
 		titleStyle.Render(title),
 		search,
+		status,
 		tbl,
 		confirm,
-		help,
+		helpStyle.Render(help),
 		"",
 	)
 }
@@ -388,6 +808,37 @@ func (m Model) viewResult() string {
 	)
 }
 
+// logLines returns how many log entries fit on screen.
+func (m Model) logLines() int {
+	if m.height == 0 {
+		return 20
+	}
+	return max(1, m.height-6) // title, blank lines and help
+}
+
+func (m Model) viewLog() string {
+	var lines []string
+	if len(m.log) == 0 {
+		lines = append(lines, searchPlaceholderStyle.Render("  No actions yet this session."))
+	}
+	end := min(len(m.log), m.logOffset+m.logLines())
+	for _, e := range m.log[m.logOffset:end] {
+		style := successStyle
+		if e.isError {
+			style = errorStyle
+		}
+		lines = append(lines, detailValueStyle.Render("  "+e.at.Format("15:04:05")+"  ")+style.Render(e.text))
+	}
+
+	help := helpStyle.Render("C-p/C-n scroll • esc/C-l back to list")
+	return lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render("Session log"),
+		strings.Join(lines, "\n"),
+		help,
+		"",
+	)
+}
+
 // buildTitle returns the title string based on queries.
 func (m Model) buildTitle() string {
 	switch len(m.queries) {
@@ -421,11 +872,34 @@ func (m Model) buildSearchBar() string {
 	return prompt + searchStyle.Render(m.search+"█")
 }
 
+// buildStatusLine renders the transient kill status. It always takes one
+// line so the table doesn't jump when a status appears or expires.
+func (m Model) buildStatusLine() string {
+	switch {
+	case m.status.text == "":
+		return " "
+	case m.status.isError:
+		return errorStyle.Render(m.status.text)
+	case m.status.expires.IsZero():
+		return warningStyle.Render(m.status.text)
+	default:
+		return successStyle.Render(m.status.text)
+	}
+}
+
 // buildHelp returns the help line.
 func (m Model) buildHelp() string {
-	help := "C-p/C-n navigate • enter select • C-r refresh • auto • C-g quit"
-	if m.force {
+	help := "C-p/C-n navigate • space mark • C-a mark all • enter kill • C-l log • C-r refresh • auto • C-g quit"
+	if n := len(m.marked); n > 0 {
+		help += fmt.Sprintf(" • %d marked", n)
+	}
+	if n := len(m.frozen); n > 0 {
+		help += fmt.Sprintf(" • %d frozen", n)
+	}
+	if m.opts.Force {
 		help += " • FORCE mode"
+	} else if m.opts.Escalate {
+		help += " • ESCALATE mode"
 	}
 	return helpStyle.Render(help)
 }
@@ -435,12 +909,38 @@ func (m Model) buildHelp() string {
 // The library's MaxWidth clips at t.width when Width() is set; by omitting
 // Width() (t.width=0) MaxWidth becomes a no-op and the right border survives.
 const (
-	colWidthSel  = 2
-	colWidthPort = 12 // enough for ":65535/tcp"
-	colWidthPID  = 8  // enough for a 7-digit PID
-	colWidthOverhead = colWidthSel + colWidthPort + colWidthPID + 2 // 2 = outer borders
+	colWidthSel     = 3  // cursor and mark indicators
+	colWidthPort    = 12 // enough for ":65535/tcp"
+	colWidthPortMax = 36 // PORT grows to list several ports, up to this width
+	colWidthPID     = 8  // enough for a 7-digit PID
 )
 
+// portColWidth returns the PORT column width: wide enough for the longest
+// visible ports label, between colWidthPort and colWidthPortMax.
+func (m Model) portColWidth() int {
+	w := colWidthPort
+	for _, item := range m.visibleItems() {
+		// +1 leaves room for the cell's trailing space
+		w = max(w, len(portsLabel(item.context.Info.Ports))+1)
+	}
+	return min(w, colWidthPortMax)
+}
+
+// colWidthOverhead returns the width taken by every column except COMMAND,
+// plus the 2 outer border chars.
+func (m Model) colWidthOverhead() int {
+	return colWidthSel + m.portColWidth() + colWidthPID + 2
+}
+
+// portsLabel joins every port a process listens on for the PORT column.
+func portsLabel(ports []process.PortBinding) string {
+	parts := make([]string, len(ports))
+	for i, b := range ports {
+		parts[i] = b.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
 // buildTable constructs a lipgloss table from the process items.
 func (m Model) buildTable() string {
 	width := m.width
@@ -481,6 +981,8 @@ func (m Model) tableStyleFunc(row, col int) lipgloss.Style {
 	default:
 		s = tableCellStyle
 		switch col {
+		case 0: // mark
+			s = s.Foreground(colorGreen)
 		case 1: // PORT
 			s = s.Foreground(colorAccent)
 		case 2: // PID
@@ -496,11 +998,11 @@ func (m Model) tableStyleFunc(row, col int) lipgloss.Style {
 	case 0:
 		return s.Width(colWidthSel)
 	case 1:
-		return s.Width(colWidthPort)
+		return s.Width(m.portColWidth())
 	case 2:
 		return s.Width(colWidthPID)
 	case 3:
-		cmdWidth := m.width - colWidthOverhead
+		cmdWidth := m.width - m.colWidthOverhead()
 		if cmdWidth < 20 {
 			cmdWidth = 20
 		}
@@ -515,12 +1017,28 @@ func (m Model) buildRow(index int, item processItem, width int) []string {
 	if index == m.cursor {
 		sel = ">"
 	}
+	if m.marked[item.key()] {
+		sel += "●"
+	}
 
-	portStr := fmt.Sprintf(":%d/%s", item.listener.Port, item.listener.Protocol)
+	portStr := portsLabel(item.context.Info.Ports)
+	if maxPort := m.portColWidth() - 1; len(portStr) > maxPort {
+		portStr = portStr[:maxPort-3] + "..."
+	}
 	pidStr := strconv.Itoa(item.context.Info.PID)
 
 	cmd := item.context.Info.Command
-	maxCmd := width - colWidthOverhead
+	if item.context.OwnerUnknown {
+		pidStr = "?"
+		cmd = kill.Describe(kill.Action{Context: item.context})
+	}
+	if s := item.context.SocketUnit; s != nil {
+		cmd = fmt.Sprintf("%s (socket unit, activates %s)", s.Unit, strings.Join(s.Activates, ", "))
+	}
+	if item.context.Frozen {
+		cmd = "[frozen] " + cmd
+	}
+	maxCmd := width - m.colWidthOverhead()
 	if maxCmd < 20 {
 		maxCmd = 20
 	}
@@ -535,13 +1053,99 @@ func (m Model) buildRow(index int, item processItem, width int) []string {
 func (m Model) buildDetailPanel() string {
 	visible := m.visibleItems()
 	if m.cursor >= len(visible) {
-		return ""
+		msg := "No processes found — waiting for listeners..."
+		if len(m.items) > 0 {
+			msg = "No ports match the filter."
+		}
+		return m.renderDetailPanel(searchPlaceholderStyle.Render(msg))
 	}
 	item := visible[m.cursor]
 	info := item.context.Info
 
+	// What enter does comes first, so the informational lines below are
+	// the ones cut when the panel is full
 	var lines []string
 
+	// Kill strategy
+	action := m.action(item.context)
+	desc := kill.Describe(action)
+	lines = append(lines, detailLabelStyle.Render("Action")+strategyStyle.Render(desc))
+
+	// Warnings
+	warnings := kill.Warnings(action)
+	if info.IsPrivileged() {
+		warnings = append(warnings, "needs sudo")
+	}
+	if m.opts.Force {
+		warnings = append(warnings, "FORCE mode")
+	}
+	if len(warnings) > 0 {
+		lines = append(lines, detailLabelStyle.Render("Warning")+warningStyle.Render(strings.Join(warnings, ", ")))
+	}
+
+	// Tags
+	var tags []string
+	if item.context.IsContainerized() {
+		name := item.context.Container.Name
+		if name == "" {
+			name = container.ShortID(item.context.Container.ID)
+		}
+		if item.context.Container.Pod != "" {
+			name = "pod/" + item.context.Container.Pod
+		}
+		tags = append(tags, tagContainerStyle.Render(fmt.Sprintf("%s:%s", item.context.Container.Runtime, name)))
+		if pod := item.context.Container.Kubernetes; pod != nil {
+			tags = append(tags, tagContainerStyle.Render("k8s:"+pod.String()))
+		}
+		if c := item.context.Container.Compose; c != nil {
+			tags = append(tags, tagContainerStyle.Render(fmt.Sprintf("compose:%s/%s", c.Project, c.Service)))
+		}
+	}
+	if item.context.IsSystemdManaged() {
+		tags = append(tags, tagSystemdStyle.Render(fmt.Sprintf("%s:%s", item.context.SystemdScope, item.context.SystemdUnit)))
+	}
+	if s := item.context.SocketUnit; s != nil {
+		tags = append(tags, tagSystemdStyle.Render(fmt.Sprintf("%s:%s", s.Scope, s.Unit)))
+	}
+	if info.IsPrivileged() {
+		tags = append(tags, tagSudoStyle.Render("sudo"))
+	}
+	if len(tags) > 0 {
+		lines = append(lines, detailLabelStyle.Render("")+strings.Join(tags, " "))
+	}
+
+	// Process tree: beside the details when there is room, else one line
+	tree := treeLines(info, detailPanelLines)
+	besideTree := len(tree) > 0 && m.width >= treeMinWidth
+	if len(tree) > 0 && !besideTree {
+		lines = append(lines, detailLabelStyle.Render("Tree")+detailValueStyle.Render(treeSummary(info)))
+	}
+
+	// Ports, including protocol and interface
+	if len(info.Ports) > 0 {
+		addrs := make([]string, len(info.Ports))
+		for i, b := range info.Ports {
+			addrs[i] = b.Address()
+		}
+		lines = append(lines, detailLabelStyle.Render("Ports")+detailValueStyle.Render(strings.Join(addrs, ", ")))
+	}
+
+	// Socket activation
+	if s := item.context.SocketUnit; s != nil {
+		socket := fmt.Sprintf("%s activates %s; listening in PID %d (%s)",
+			s.Unit, strings.Join(s.Activates, ", "), info.PID, info.Command)
+		lines = append(lines, detailLabelStyle.Render("Socket")+detailValueStyle.Render(socket))
+	} else if s := item.context.ActivatedBy; s != nil {
+		lines = append(lines, detailLabelStyle.Render("Socket")+detailValueStyle.Render("activated by "+s.Unit))
+	}
+
+	// Compose project and service
+	if c := item.context.Container; c != nil && c.Compose != nil {
+		compose := fmt.Sprintf("project %s, service %s (%d of %d containers)",
+			c.Compose.Project, c.Compose.Service, len(c.Compose.ServiceContainers), len(c.Compose.ProjectContainers))
+		lines = append(lines, detailLabelStyle.Render("Compose")+detailValueStyle.Render(compose))
+	}
+
 	// User
 	if info.User != "" {
 		lines = append(lines, detailLabelStyle.Render("User")+detailValueStyle.Render(info.User))
@@ -563,57 +1167,114 @@ func (m Model) buildDetailPanel() string {
 		lines = append(lines, detailLabelStyle.Render("Uptime")+detailValueStyle.Render(formatDuration(uptime)))
 	}
 
-	// Children
-	if len(info.Children) > 0 {
-		lines = append(lines, detailLabelStyle.Render("Children")+detailValueStyle.Render(strconv.Itoa(len(info.Children))))
+	// Paused state
+	if item.context.Frozen {
+		state := "frozen — enter resumes it"
+		if _, ok := m.frozen[item.key()]; ok {
+			state = "frozen by zap — enter resumes it"
+		}
+		lines = append(lines, detailLabelStyle.Render("State")+warningStyle.Render(state))
 	}
 
-	// Kill strategy
-	strategy := kill.RecommendedStrategy(item.context)
-	action := kill.Action{
-		Strategy: strategy,
-		Context:  item.context,
-		Force:    m.force,
+	// Cgroup
+	if g := item.context.Cgroup; g != nil {
+		lines = append(lines, detailLabelStyle.Render("Cgroup")+detailValueStyle.Render(
+			fmt.Sprintf("%s (%d processes)", g.Path, len(g.Members))))
 	}
-	desc := kill.Describe(action)
-	lines = append(lines, detailLabelStyle.Render("Action")+strategyStyle.Render(desc))
 
-	// Warnings
-	var warnings []string
-	if info.IsPrivileged() {
-		warnings = append(warnings, "needs sudo")
-	}
-	if len(info.Children) > 0 {
-		warnings = append(warnings, fmt.Sprintf("%d children affected", len(info.Children)))
+	content := strings.Join(lines, "\n")
+	if besideTree {
+		left := lipgloss.NewStyle().Width(m.width - treeWidth - 6).Render(content)
+		right := lipgloss.NewStyle().Width(treeWidth).Render(strings.Join(tree, "\n"))
+		content = lipgloss.JoinHorizontal(lipgloss.Top, left, "  ", right)
 	}
-	if m.force {
-		warnings = append(warnings, "FORCE mode")
+	return m.renderDetailPanel(content)
+}
+
+const (
+	detailPanelLines = 8
+	treeWidth        = 40 // width of the process tree column
+	treeMinWidth     = 110
+	treeAncestors    = 3 // nearest ancestors shown in the tree
+)
+
+// treeLines renders the processes around info as an indented tree of at
+// most maxLines lines: its nearest ancestors, itself, then its
+// descendants. It returns nil when the process stands alone.
+func treeLines(info process.Info, maxLines int) []string {
+	if len(info.Ancestors) == 0 && len(info.Descendants) == 0 {
+		return nil
 	}
-	if len(warnings) > 0 {
-		lines = append(lines, detailLabelStyle.Render("Warning")+warningStyle.Render(strings.Join(warnings, ", ")))
+	launcher, _ := info.Launcher()
+	node := func(depth int, n process.Node) string {
+		prefix := ""
+		if depth > 0 {
+			prefix = strings.Repeat("  ", depth-1) + "└ "
+		}
+		return truncate(fmt.Sprintf("%s%s %d", prefix, commandName(n.Command), n.PID), treeWidth)
 	}
 
-	// Tags
-	var tags []string
-	if item.context.IsContainerized() {
-		name := item.context.Container.Name
-		if name == "" {
-			name = container.ShortID(item.context.Container.ID)
+	var lines []string
+	ancestors := info.Ancestors
+	if len(ancestors) > treeAncestors {
+		ancestors = ancestors[:treeAncestors]
+		lines = append(lines, detailValueStyle.Render("…"))
+	}
+	depth := 0
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		line := node(depth, ancestors[i])
+		if ancestors[i].PID == launcher.PID {
+			line += " (launcher)"
 		}
-		tags = append(tags, tagContainerStyle.Render(fmt.Sprintf("%s:%s", item.context.Container.Runtime, name)))
+		lines = append(lines, detailValueStyle.Render(line))
+		depth++
 	}
-	if item.context.IsSystemdManaged() {
-		tags = append(tags, tagSystemdStyle.Render(item.context.SystemdUnit))
+	self := process.Node{PID: info.PID, Command: info.Command}
+	lines = append(lines, strategyStyle.Render(node(depth, self)+" ◀"))
+	for i, d := range info.Descendants {
+		if len(lines) == maxLines-1 && i < len(info.Descendants)-1 {
+			more := fmt.Sprintf("%s+%d more", strings.Repeat("  ", depth+d.Depth-1)+"└ ", len(info.Descendants)-i)
+			lines = append(lines, detailValueStyle.Render(more))
+			break
+		}
+		lines = append(lines, detailValueStyle.Render(node(depth+d.Depth, d)))
 	}
-	if info.IsPrivileged() {
-		tags = append(tags, tagSudoStyle.Render("sudo"))
+	return lines
+}
+
+// treeSummary describes the processes around info on one line, e.g.
+// "npm › node 4133 › 2 descendants".
+func treeSummary(info process.Info) string {
+	var parts []string
+	if launcher, ok := info.Launcher(); ok {
+		parts = append(parts, commandName(launcher.Command))
+	} else if len(info.Ancestors) > 0 {
+		parts = append(parts, commandName(info.Ancestors[0].Command))
 	}
-	if len(tags) > 0 {
-		lines = append(lines, detailLabelStyle.Render("")+strings.Join(tags, " "))
+	parts = append(parts, fmt.Sprintf("%s %d", commandName(info.Command), info.PID))
+	if n := len(info.Descendants); n == 1 {
+		parts = append(parts, "1 descendant")
+	} else if n > 1 {
+		parts = append(parts, fmt.Sprintf("%d descendants", n))
+	}
+	return strings.Join(parts, " › ")
+}
+
+// truncate shortens s to at most n runes, marking the cut with "...".
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
 	}
+	return string(r[:n-3]) + "..."
+}
 
-	content := strings.Join(lines, "\n")
-	const detailPanelLines = 7
+// renderDetailPanel frames content in the fixed-height detail panel,
+// dropping the last lines that don't fit.
+func (m Model) renderDetailPanel(content string) string {
+	if lines := strings.Split(content, "\n"); len(lines) > detailPanelLines {
+		content = strings.Join(lines[:detailPanelLines], "\n")
+	}
 	width := m.width
 	if width > 0 {
 		return detailPanelStyle.Width(width - 2).Height(detailPanelLines).Render(content)
@@ -621,28 +1282,100 @@ func (m Model) buildDetailPanel() string {
 	return detailPanelStyle.Height(detailPanelLines).Render(content)
 }
 
+// affected lists the processes signalled by a cgroup, tree, launcher or
+// group action, or frozen by a cgroup pause, e.g. "1234 node, 1240
+// esbuild"; n is their number. Other strategies return 0.
+func affected(action kill.Action) (label string, n int) {
+	var parts []string
+	for _, m := range kill.CgroupTargets(action) {
+		parts = append(parts, fmt.Sprintf("%d %s", m.PID, m.Command))
+	}
+	for _, node := range append(kill.TreeTargets(action), kill.GroupTargets(action)...) {
+		parts = append(parts, fmt.Sprintf("%d %s", node.PID, commandName(node.Command)))
+	}
+	return strings.Join(parts, ", "), len(parts)
+}
+
+// commandName returns the program name of a command line, e.g. "node" for
+// "/usr/bin/node server.js".
+func commandName(cmd string) string {
+	fields := strings.Fields(cmd)
+	if len(fields) == 0 {
+		return cmd
+	}
+	return filepath.Base(fields[0])
+}
+
+// countsChildren reports whether an action's children go down with the
+// target; a signal or SIGSTOP leaves them running, and kill.Warnings says so.
+func countsChildren(action kill.Action) bool {
+	return action.Strategy != kill.StrategySignal && action.Strategy != kill.StrategyPause
+}
+
 // buildConfirmPrompt renders the inline confirm prompt.
 func (m Model) buildConfirmPrompt() string {
-	visible := m.visibleItems()
-	if m.cursor >= len(visible) {
+	targets := m.targets()
+	if len(targets) == 0 {
 		return ""
 	}
-	item := visible[m.cursor]
-	strategy := kill.RecommendedStrategy(item.context)
-	action := kill.Action{
-		Strategy: strategy,
-		Context:  item.context,
-		Force:    m.force,
-	}
-	desc := kill.Describe(action)
 
+	actions := m.confirmActions()
 	var lines []string
-	lines = append(lines, confirmPromptStyle.Render("Kill? ")+confirmDescStyle.Render(desc+" [y/n]"))
-
-	if len(item.context.Info.Children) > 0 {
-		lines = append(lines, warningStyle.Render(
-			fmt.Sprintf("Warning: %d child processes will be affected", len(item.context.Info.Children)),
-		))
+	if len(targets) == 1 {
+		item := targets[0]
+		desc := kill.Describe(actions[0])
+		lines = append(lines, confirmPromptStyle.Render("Kill? ")+confirmDescStyle.Render(desc+" [y/n]"))
+		var choices []string
+		for _, strategy := range kill.AvailableStrategies(item.context) {
+			if strategy == actions[0].Strategy {
+				choices = append(choices, strategyStyle.Render(strategy.String()))
+			} else {
+				choices = append(choices, confirmDescStyle.Render(strategy.String()))
+			}
+		}
+		lines = append(lines, detailLabelStyle.Render("Strategy")+strings.Join(choices, confirmDescStyle.Render(" · ")))
+
+		if label, n := m.affectedAt(0); n > 0 {
+			lines = append(lines, warningStyle.Render(
+				fmt.Sprintf("Warning: %d processes will be affected: %s", n, label),
+			))
+		} else if len(item.context.Info.Children) > 0 && countsChildren(actions[0]) {
+			lines = append(lines, warningStyle.Render(
+				fmt.Sprintf("Warning: %d child processes will be affected", len(item.context.Info.Children)),
+			))
+		}
+		if ports := item.context.Info.Ports; len(ports) > 1 {
+			lines = append(lines, warningStyle.Render(
+				fmt.Sprintf("Warning: also frees %d ports: %s", len(ports), portsLabel(ports)),
+			))
+		}
+		for _, w := range kill.Warnings(actions[0]) {
+			lines = append(lines, warningStyle.Render("Warning: "+w))
+		}
+	} else {
+		lines = append(lines, confirmPromptStyle.Render(fmt.Sprintf("Kill %d processes? ", len(targets)))+confirmDescStyle.Render("[y/n]"))
+		children := 0
+		var warnings []string
+		for i, item := range targets {
+			desc := kill.Describe(actions[i])
+			lines = append(lines, confirmDescStyle.Render(fmt.Sprintf("  %s  %s", desc, portsLabel(item.context.Info.Ports))))
+			if label, n := m.affectedAt(i); n > 0 {
+				lines = append(lines, confirmDescStyle.Render("    "+label))
+			} else if countsChildren(actions[i]) {
+				children += len(item.context.Info.Children)
+			}
+			for _, w := range kill.Warnings(actions[i]) {
+				warnings = append(warnings, fmt.Sprintf("Warning: %s: %s", desc, w))
+			}
+		}
+		if children > 0 {
+			lines = append(lines, warningStyle.Render(
+				fmt.Sprintf("Warning: %d child processes will be affected", children),
+			))
+		}
+		for _, w := range warnings {
+			lines = append(lines, warningStyle.Render(w))
+		}
 	}
 
 	content := strings.Join(lines, "\n")